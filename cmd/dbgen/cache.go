@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/sha256"
+	"dbgen/pkg/config"
+	"dbgen/pkg/modelgen"
+	"dbgen/pkg/schemas"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/thorn-jmh/errorst"
+	"hash"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// >>>>>>>>>>>> cache skips jobs whose inputs are unchanged since last generation >>>>>>>>>>>>>>>
+
+// CacheFile is the name of cache file in each output directory.
+const CacheFile = ".dbgen-cache.json"
+
+// cacheVersion is bumped on incompatible changes of cache file or hash.
+const cacheVersion = 1
+
+// cacheEntry records the last generation of a package.
+type cacheEntry struct {
+	Hash  string   `json:"hash"`  // hash of inputs
+	Files []string `json:"files"` // generated files, relative to output directory
+}
+
+// cacheDoc is the content of cache file.
+type cacheDoc struct {
+	Version int                    `json:"version"`
+	Entries map[string]*cacheEntry `json:"entries"` // by absolute schema paths of package
+}
+
+// cache holds cache files of output directories, safe for concurrent use.
+type cache struct {
+	mu    sync.Mutex
+	docs  map[string]*cacheDoc // by output directory
+	dirty map[string]bool      // output directories to save
+}
+
+// loadCache reads cache files of output directories of jobs,
+// missing or invalid cache files are treated as empty.
+func loadCache(jobs []job) *cache {
+	c := &cache{
+		docs:  make(map[string]*cacheDoc),
+		dirty: make(map[string]bool),
+	}
+	for _, j := range jobs {
+		dir := filepath.Clean(j.settings.Output)
+		if _, ok := c.docs[dir]; ok {
+			continue
+		}
+
+		doc := &cacheDoc{}
+		data, err := os.ReadFile(filepath.Join(dir, CacheFile))
+		if err != nil || json.Unmarshal(data, doc) != nil || doc.Version != cacheVersion || doc.Entries == nil {
+			doc = &cacheDoc{Version: cacheVersion, Entries: make(map[string]*cacheEntry)}
+		}
+		c.docs[dir] = doc
+	}
+	return c
+}
+
+// fresh reports whether outputs of g were generated from inputs of hash,
+// and still exist.
+func (c *cache) fresh(g group, hash string) bool {
+	dir := filepath.Clean(g.settings().Output)
+	c.mu.Lock()
+	entry := c.docs[dir].Entries[cacheKey(g)]
+	c.mu.Unlock()
+
+	if entry == nil || entry.Hash != hash {
+		return false
+	}
+	for _, file := range entry.Files {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// store records outputs of g generated from inputs of hash.
+func (c *cache) store(g group, hash string, files []outputFile) {
+	dir := filepath.Clean(g.settings().Output)
+	entry := &cacheEntry{Hash: hash}
+	for _, file := range files {
+		if rel, err := filepath.Rel(dir, file.path); err == nil {
+			entry.Files = append(entry.Files, rel)
+		}
+	}
+	sort.Strings(entry.Files)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.docs[dir].Entries[cacheKey(g)] = entry
+	c.dirty[dir] = true
+}
+
+// save writes changed cache files.
+func (c *cache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for dir := range c.dirty {
+		data, err := json.MarshalIndent(c.docs[dir], "", "  ")
+		if err != nil {
+			return errorst.Wrap(err, "failed to marshal cache of %s", dir)
+		}
+		if err := os.WriteFile(filepath.Join(dir, CacheFile), append(data, '\n'), 0644); err != nil {
+			return errorst.WrapWithCode(err, ExitIO, "failed to save cache of %s", dir)
+		}
+		delete(c.dirty, dir)
+	}
+	return nil
+}
+
+// cacheKey joins absolute schema paths of g.
+func cacheKey(g group) string {
+	paths := make([]string, 0, len(g.jobs))
+	for _, j := range g.jobs {
+		path := j.schema
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if j.component != "" {
+			path += schemas.ComponentsRef + j.component
+		}
+		paths = append(paths, path)
+	}
+	return strings.Join(paths, string(filepath.ListSeparator))
+}
+
+// cacheable reports whether g neither reads stdin nor writes stdout.
+func (g group) cacheable() bool {
+	if g.settings().Output == stdio {
+		return false
+	}
+	for _, j := range g.jobs {
+		if j.schema == stdio {
+			return false
+		}
+	}
+	return true
+}
+
+// groupHash hashes inputs of all jobs in g.
+func groupHash(g group) (string, error) {
+	h := sha256.New()
+	for _, j := range g.jobs {
+		hash, err := jobHash(j)
+		if err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(h, "%s %s\n", j.source(), hash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// jobHash hashes everything outputs of j depend on: the dbgen executable,
+// settings, the schema with files it references, and templates.
+func jobHash(j job) (string, error) {
+	h := sha256.New()
+
+	// first: executable and settings
+	if exe, err := os.Executable(); err == nil {
+		if info, err := os.Stat(exe); err == nil {
+			_, _ = fmt.Fprintf(h, "exe %s %d\n", info.ModTime().Format(time.RFC3339Nano), info.Size())
+		}
+	}
+	settings, err := json.Marshal(struct {
+		Version  int
+		FromIR   bool
+		Settings config.Settings
+	}{cacheVersion, fromIR, j.settings})
+	if err != nil {
+		return "", errorst.Wrap(err, "failed to marshal settings of %s", j.schema)
+	}
+	_, _ = fmt.Fprintf(h, "settings %s\n", settings)
+
+	// second: schema, which refers to no other documents
+	if err := hashFile(h, j.schema); err != nil {
+		return "", err
+	}
+
+	// third: templates
+	if j.settings.HasBackend(config.BackendTemplate) {
+		tmpls, err := filepath.Glob(filepath.Join(j.settings.Templates, "*"+modelgen.TemplateExt))
+		if err != nil {
+			return "", errorst.WrapWithCode(err, ExitUsage, "failed to list templates")
+		}
+		sort.Strings(tmpls)
+		for _, tmpl := range tmpls {
+			if err := hashFile(h, tmpl); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h hash.Hash, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		_, _ = fmt.Fprintf(h, "missing %s\n", path)
+		return nil
+	} else if err != nil {
+		return errorst.WrapWithCode(err, ExitIO, "failed to read %s", path)
+	}
+	_, _ = fmt.Fprintf(h, "file %s %d\n", path, len(data))
+	_, _ = h.Write(data)
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	jobs := []job{generatorJob(t, dir, "order.json", testSchema)}
+	schema := jobs[0].schema
+	out := filepath.Join(dir, "model", "Order.go")
+	const edited = "// edited\n"
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T)
+		force   bool
+		hit     bool // output is kept as edited
+	}{
+		{name: "first run"},
+		{name: "unchanged", hit: true},
+		{name: "forced", force: true},
+		{
+			name: "schema changed",
+			prepare: func(t *testing.T) {
+				if err := os.WriteFile(schema, []byte(strings.Replace(testSchema, `"no"`, `"code"`, 1)), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "output removed",
+			prepare: func(t *testing.T) {
+				if err := os.Remove(out); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "cache removed",
+			prepare: func(t *testing.T) {
+				if err := os.Remove(filepath.Join(dir, "model", CacheFile)); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{name: "unchanged again", hit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t)
+			}
+			if _, err := os.Stat(out); err == nil {
+				if err := os.WriteFile(out, []byte(edited), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			force = tt.force
+			defer func() { force = false }()
+			if err := genAll(&bytes.Buffer{}, jobs); err != nil {
+				t.Fatalf("genAll() = %v", err)
+			}
+			content, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hit := string(content) == edited; hit != tt.hit {
+				t.Errorf("cache hit = %v, want %v", hit, tt.hit)
+			}
+		})
+	}
+}
+
+func TestCacheSkipsStdio(t *testing.T) {
+	dir := t.TempDir()
+	j := generatorJob(t, dir, "order.json", testSchema)
+	j.settings.Output = stdio
+	for i := 0; i < 2; i++ {
+		var w bytes.Buffer
+		if err := genAll(&w, []job{j}); err != nil {
+			t.Fatalf("genAll() = %v", err)
+		}
+		if !strings.Contains(w.String(), "type Order struct") {
+			t.Errorf("run %d printed:\n%s", i, w.String())
+		}
+	}
+}
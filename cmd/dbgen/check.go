@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"dbgen/pkg/modelgen"
+	"errors"
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/thorn-jmh/errorst"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// checkAll generates files of jobs in memory and compares them with
+// files on disk. Diffs of stale files are printed to w, and generated
+// files in output directories without a schema are reported as orphans.
+func checkAll(w io.Writer, jobs []job) error {
+	// first: render all packages
+	groups := groupJobs(jobs)
+	results, err := renderGroups(groups)
+	if err != nil {
+		return err
+	}
+	if err := checkPaths(results, groups); err != nil {
+		return err
+	}
+
+	// second: compare with files on disk, in order of jobs
+	var (
+		stale     []string
+		generated = make(map[string]bool)
+		dirs      = make(map[string]bool)
+	)
+	for i, files := range results {
+		dirs[filepath.Clean(groups[i].settings().Output)] = true
+		for _, file := range files {
+			path := filepath.Clean(file.path)
+			generated[path] = true
+
+			diff, err := fileDiff(path, file.content)
+			if err != nil {
+				return err
+			}
+			if diff != "" {
+				stale = append(stale, path)
+				_, _ = io.WriteString(w, diff)
+			}
+		}
+	}
+
+	// third: find orphaned files
+	orphans, err := findOrphans(dirs, generated)
+	if err != nil {
+		return err
+	}
+	for _, orphan := range orphans {
+		_, _ = fmt.Fprintf(w, "orphaned generated file: %s\n", orphan)
+	}
+
+	if len(stale) > 0 || len(orphans) > 0 {
+		return errorst.NewErrorWithCode(ExitStale, "%d stale and %d orphaned generated files", len(stale), len(orphans))
+	}
+	return nil
+}
+
+// fileDiff returns the unified diff from file at path to content,
+// or "" if they are the same.
+func fileDiff(path string, content []byte) (string, error) {
+	old, err := os.ReadFile(path)
+	fromFile := path
+	if errors.Is(err, fs.ErrNotExist) {
+		fromFile = os.DevNull
+	} else if err != nil {
+		return "", errorst.WrapWithCode(err, ExitIO, "failed to read %s", path)
+	}
+	if bytes.Equal(old, content) {
+		return "", nil
+	}
+
+	var oldLines []string
+	if len(old) > 0 {
+		oldLines = difflib.SplitLines(string(old))
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        oldLines,
+		B:        difflib.SplitLines(string(content)),
+		FromFile: fromFile,
+		ToFile:   path,
+		Context:  3,
+	})
+	if err != nil {
+		return "", errorst.Wrap(err, "failed to diff %s", path)
+	}
+	return diff, nil
+}
+
+// findOrphans returns files in dirs which are generated by dbgen,
+// but not in generated.
+func findOrphans(dirs map[string]bool, generated map[string]bool) ([]string, error) {
+	var orphans []string
+	for dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, errorst.WrapWithCode(err, ExitIO, "failed to read directory %s", dir)
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !entry.Type().IsRegular() || generated[path] {
+				continue
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, errorst.WrapWithCode(err, ExitIO, "failed to read %s", path)
+			}
+			if modelgen.IsGenerated(content) {
+				orphans = append(orphans, path)
+			}
+		}
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
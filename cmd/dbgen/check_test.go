@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"dbgen/pkg/config"
+	"dbgen/pkg/modelgen"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"$id": "Order",
+	"type": "object",
+	"properties": {
+		"no": {"type": "string"},
+		"items": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "string"}}}}
+	}
+}`
+
+// generatorJob writes schema to dir, and returns its job generating into dir/model.
+func generatorJob(t *testing.T, dir, name, schema string) job {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings := config.DefaultSettings()
+	settings.Output = filepath.Join(dir, "model")
+	opts, err := settings.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job{schema: path, settings: settings, generator: modelgen.NewGenerator(settings.Package, opts)}
+}
+
+func TestCheckAll(t *testing.T) {
+	dir := t.TempDir()
+	jobs := []job{generatorJob(t, dir, "order.json", testSchema)}
+	out := filepath.Join(dir, "model")
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T)
+		stale   bool
+		report  []string // substrings of report
+	}{
+		{
+			name:   "not generated",
+			stale:  true,
+			report: []string{"--- " + os.DevNull, "+++ " + filepath.Join(out, "Order.go")},
+		},
+		{
+			name: "generated",
+			prepare: func(t *testing.T) {
+				if err := genAll(&bytes.Buffer{}, jobs); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "edited",
+			prepare: func(t *testing.T) {
+				path := filepath.Join(out, "Order.go")
+				content, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				content = bytes.Replace(content, []byte("Order"), []byte("Purchase"), 1)
+				if err := os.WriteFile(path, content, 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			stale:  true,
+			report: []string{"--- " + filepath.Join(out, "Order.go"), "-", "+"},
+		},
+		{
+			name: "orphaned",
+			prepare: func(t *testing.T) {
+				force = true
+				defer func() { force = false }()
+				if err := genAll(&bytes.Buffer{}, jobs); err != nil {
+					t.Fatal(err)
+				}
+				orphan := "// " + modelgen.GeneratedHeader + "\n\npackage model\n"
+				if err := os.WriteFile(filepath.Join(out, "legacy.go"), []byte(orphan), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(out, "handwritten.go"), []byte("package model\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			stale:  true,
+			report: []string{"orphaned generated file: " + filepath.Join(out, "legacy.go")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t)
+			}
+			var report bytes.Buffer
+			err := checkAll(&report, jobs)
+			if !tt.stale {
+				if err != nil || report.Len() > 0 {
+					t.Fatalf("checkAll() = %v, report:\n%s", err, report.String())
+				}
+				return
+			}
+			if exitCode(err) != int(ExitStale) {
+				t.Fatalf("checkAll() = %v, want exit code %d", err, ExitStale)
+			}
+			for _, want := range tt.report {
+				if !strings.Contains(report.String(), want) {
+					t.Errorf("report does not contain %q:\n%s", want, report.String())
+				}
+			}
+			if strings.Contains(report.String(), "handwritten.go") {
+				t.Errorf("handwritten file reported:\n%s", report.String())
+			}
+		})
+	}
+}
@@ -1,33 +1,97 @@
 package main
 
 import (
-	"fmt"
+	"dbgen/pkg/config"
+	"dbgen/pkg/modelgen"
 	"github.com/spf13/cobra"
+	"github.com/thorn-jmh/errorst"
+	"runtime"
 )
 
 var (
 	outputDir   string
 	packageName string
+	genDDL      bool
+	fromIR      bool
+	templateDir string
+	workers     int
+	failFast    bool
+	check       bool
+	force       bool
+	dryRun      bool
+	split       bool
+
+	typeStyle   string
+	fieldStyle  string
+	columnStyle string
+	tableStyle  string
+	initialisms []string
+	namePolicy  string
+	keyStrategy string
+	mixins      []string
+	mixinsAll   []string
+
+	components []string
+	configPath string
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "dbgen [-o <outputDir>] [-p <package name>] <schema...>",
 	Short: "Generate database access code",
-	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			_ = cmd.Help()
-			return
+	Long: "Generate database access code from schemas in arguments, or from " +
+		"inputs of config file " + config.FileName + " if no argument is given. " +
+		"Schema - reads stdin. OpenAPI 3.0 and 3.1 documents are read by their " +
+		"components.schemas, each generated as a schema.",
+	Args:          cobra.ArbitraryArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobs, err := loadJobs(cmd, args)
+		if err != nil {
+			return err
 		}
-
-		for _, schemaPath := range args {
-			if err := gen(schemaPath); err != nil {
-				fmt.Printf("%v", err)
+		if len(jobs) == 0 {
+			return cmd.Help()
+		}
+		if err := checkOutputs(jobs); err != nil {
+			return err
+		}
+		if check {
+			if err := checkNoStdio(jobs, false); err != nil {
+				return err
 			}
+			return checkAll(cmd.OutOrStdout(), jobs)
 		}
+		return genAll(cmd.OutOrStdout(), jobs)
 	},
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&outputDir, "output", "o", "./model", "output directory")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return errorst.WrapWithCode(err, ExitUsage, "invalid flags, run '%s --help' for usage", cmd.CommandPath())
+	})
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "config file (default to "+config.FileName+" searched upward from working directory)")
+	rootCmd.PersistentFlags().StringVarP(&outputDir, "output", "o", "./model", "output directory, - for stdout")
 	rootCmd.PersistentFlags().StringVarP(&packageName, "package", "p", "model", "package name")
+	rootCmd.PersistentFlags().BoolVar(&genDDL, "ddl", false, "also generate SQL DDL of indexes")
+	rootCmd.PersistentFlags().StringVar(&templateDir, "template", "", "generate by text/template files (*.tmpl) in this directory instead")
+	rootCmd.Flags().IntVarP(&workers, "jobs", "j", runtime.NumCPU(), "number of schemas generated in parallel")
+	rootCmd.PersistentFlags().BoolVar(&failFast, "fail-fast", false, "stop at the first failed schema")
+	rootCmd.Flags().BoolVar(&check, "check", false, "compare generated code with files on disk instead of writing, print diffs of stale files and report orphaned ones")
+	rootCmd.Flags().BoolVar(&force, "force", false, "regenerate all schemas, even if unchanged since last generation")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "list files which would be written, without writing them")
+	rootCmd.PersistentFlags().BoolVar(&split, "split", false, "generate one Go file per type, instead of "+modelgen.SharedFile+" and a file per schema")
+	rootCmd.Flags().BoolVar(&fromIR, "ir", false, "read IR files printed by dbgen ir instead of schemas")
+
+	// name styles, one of big-camel, camel, snake, kebab, Go names are big-camel or camel
+	rootCmd.PersistentFlags().StringVar(&typeStyle, "type-style", "big-camel", "name style of types, big-camel or camel")
+	rootCmd.PersistentFlags().StringVar(&fieldStyle, "field-style", "big-camel", "name style of struct fields, big-camel or camel")
+	rootCmd.PersistentFlags().StringVar(&columnStyle, "column-style", "", "name style of columns (default to gorm naming)")
+	rootCmd.PersistentFlags().StringVar(&tableStyle, "table-style", "", "name style of tables (default to gorm naming)")
+	rootCmd.PersistentFlags().StringSliceVar(&initialisms, "initialisms", nil, "extra initialisms to upper case, e.g. SKU")
+	rootCmd.PersistentFlags().StringVar(&namePolicy, "name-policy", "path", "how to name types, path or short")
+	rootCmd.PersistentFlags().StringVar(&keyStrategy, "key-strategy", "auto", "generated primary key, one of auto, uuid, ulid")
+	rootCmd.PersistentFlags().StringSliceVar(&mixins, "mixins", nil, "mixins applied to root tables, e.g. gorm.Model, timestamps, soft-delete, audit")
+	rootCmd.PersistentFlags().StringSliceVar(&mixinsAll, "mixins-all", nil, "mixins applied to all tables")
+	rootCmd.PersistentFlags().StringSliceVar(&components, "component", nil, "components.schemas of OpenAPI documents to generate (default all objects)")
 }
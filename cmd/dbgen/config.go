@@ -0,0 +1,252 @@
+package main
+
+import (
+	"dbgen/pkg/config"
+	"dbgen/pkg/modelgen"
+	"dbgen/pkg/schemas"
+	"github.com/spf13/cobra"
+	"github.com/thorn-jmh/errorst"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// job generates one schema with its settings.
+type job struct {
+	schema    string
+	component string   // component schema, if schema is an OpenAPI document
+	declared  []string // components generated by jobs of the same document
+	settings  config.Settings
+	generator *modelgen.Generator
+}
+
+// source is the schema file of j, followed by the pointer of its component.
+func (j job) source() string {
+	if j.component == "" {
+		return j.schema
+	}
+	return j.schema + schemas.ComponentsRef + j.component
+}
+
+// loadConfig loads config from --config, or searches it
+// upward from working directory. It returns nil if not found.
+func loadConfig() (*config.Config, error) {
+	path := configPath
+	if path == "" {
+		var err error
+		if path, err = config.Find("."); err != nil || path == "" {
+			return nil, err
+		}
+	}
+	return config.Load(path)
+}
+
+// loadJobs returns jobs of schemas in args, or of config inputs if args
+// is empty. Settings are from config, overridden by flags set explicitly.
+func loadJobs(cmd *cobra.Command, args []string) ([]job, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, configError(err)
+	}
+
+	// first: collect schemas with settings in config
+	var cfgJobs []config.Job
+	switch {
+	case len(args) > 0:
+		for _, arg := range args {
+			settings := config.DefaultSettings()
+			if cfg != nil {
+				settings = cfg.Resolve(arg)
+			}
+			cfgJobs = append(cfgJobs, config.Job{Schema: arg, Settings: settings})
+		}
+	case cfg != nil:
+		if cfgJobs, err = cfg.Jobs(); err != nil {
+			return nil, configError(err)
+		}
+	}
+
+	// second: apply flags and create generators
+	flags := flagSettings(cmd)
+	jobs := make([]job, 0, len(cfgJobs))
+	for _, cfgJob := range cfgJobs {
+		settings := cfgJob.Settings
+		settings.Merge(flags)
+		if cmd.Flags().Changed("template") {
+			settings.Backends = replaceBackend(settings.Backends, config.BackendGo, config.BackendTemplate)
+		}
+		if genDDL && !settings.HasBackend(config.BackendDDL) {
+			settings.Backends = append(settings.Backends, config.BackendDDL)
+		}
+		if settings.HasBackend(config.BackendTemplate) && settings.Templates == "" {
+			return nil, errorst.WrapWithCode(config.ErrInvalidConfig, ExitUsage, "backend %s of %s without templates", config.BackendTemplate, cfgJob.Schema)
+		}
+
+		opts, err := settings.Options()
+		if err != nil {
+			return nil, errorst.WrapWithCode(err, ExitUsage, "invalid settings of %s", cfgJob.Schema)
+		}
+		jobs = append(jobs, job{
+			schema:    cfgJob.Schema,
+			settings:  settings,
+			generator: modelgen.NewGenerator(settings.Package, opts),
+		})
+	}
+	return expandComponents(jobs)
+}
+
+// expandComponents replaces jobs of OpenAPI documents with a job for each
+// component in --component, or for each object component if it is not set.
+// Components refer to those generated by other jobs by their types.
+func expandComponents(jobs []job) ([]job, error) {
+	if fromIR {
+		return jobs, nil
+	}
+
+	var (
+		expanded []job
+		found    bool
+	)
+	for _, j := range jobs {
+		data, err := readInput(j)
+		if err != nil || !schemas.IsOpenAPI(data) {
+			// unreadable schemas fail later, as others
+			expanded = append(expanded, j)
+			continue
+		}
+		found = true
+		doc, err := parseOpenAPI(j, data)
+		if err != nil {
+			return nil, errorst.WrapWithCode(err, ExitParse, "failed to parse OpenAPI document %s", j.schema)
+		}
+
+		names := components
+		if len(names) == 0 {
+			names = doc.Components(true)
+		} else {
+			all := doc.Components(false)
+			for _, name := range names {
+				if !slices.Contains(all, name) {
+					return nil, errorst.NewErrorWithCode(ExitUsage, "component %s not found in %s, components are %s", name, j.schema, strings.Join(all, ", "))
+				}
+			}
+		}
+		for _, name := range names {
+			j.component = name
+			j.declared = names
+			expanded = append(expanded, j)
+		}
+	}
+	if len(components) > 0 && !found {
+		return nil, errorst.NewErrorWithCode(ExitUsage, "--component is only for OpenAPI documents")
+	}
+	return expanded, nil
+}
+
+// flagSettings returns settings of flags set explicitly.
+func flagSettings(cmd *cobra.Command) config.Settings {
+	var s config.Settings
+	for _, flag := range []struct {
+		name string
+		set  func()
+	}{
+		{"output", func() { s.Output = outputDir }},
+		{"package", func() { s.Package = packageName }},
+		{"template", func() { s.Templates = templateDir }},
+		{"split", func() { s.Split = &split }},
+		{"type-style", func() { s.Naming.Types = typeStyle }},
+		{"field-style", func() { s.Naming.Fields = fieldStyle }},
+		{"column-style", func() { s.Naming.Columns = columnStyle }},
+		{"table-style", func() { s.Naming.Tables = tableStyle }},
+		{"initialisms", func() { s.Naming.Initialisms = initialisms }},
+		{"name-policy", func() { s.Naming.Policy = namePolicy }},
+		{"key-strategy", func() { s.KeyStrategy = keyStrategy }},
+		{"mixins", func() { s.Mixins.Root = mixins }},
+		{"mixins-all", func() { s.Mixins.All = mixinsAll }},
+	} {
+		if cmd.Flags().Changed(flag.name) {
+			flag.set()
+		}
+	}
+	return s
+}
+
+// replaceBackend replaces old with new in backends, or appends new.
+func replaceBackend(backends []string, old string, new string) []string {
+	var (
+		replaced []string
+		found    bool
+	)
+	for _, b := range backends {
+		switch b {
+		case old:
+			continue
+		case new:
+			found = true
+		}
+		replaced = append(replaced, b)
+	}
+	if !found {
+		replaced = append(replaced, new)
+	}
+	return replaced
+}
+
+// checkNoStdio returns a usage error if any job writes stdout,
+// or reads stdin if input is set.
+func checkNoStdio(jobs []job, input bool) error {
+	for _, j := range jobs {
+		if j.settings.Output == stdio {
+			return errorst.NewErrorWithCode(ExitUsage, "output %s is not supported here", stdio)
+		}
+		if input && j.schema == stdio {
+			return errorst.NewErrorWithCode(ExitUsage, "schema %s is not supported here", stdio)
+		}
+	}
+	return nil
+}
+
+// checkOutputs returns a usage error if jobs would write the same files,
+// that is a schema generated twice into a directory, or packages of
+// different names in a directory. Outputs to stdout never collide.
+func checkOutputs(jobs []job) error {
+	var (
+		sources  = make(map[[2]string]bool)
+		packages = make(map[string]string)
+	)
+	for _, j := range jobs {
+		if j.settings.Output == stdio {
+			continue
+		}
+		dir := filepath.Clean(j.settings.Output)
+		key := [2]string{dir, j.source()}
+		if sources[key] {
+			return errorst.NewErrorWithCode(ExitUsage, "%s is generated into %s more than once", j.source(), dir)
+		}
+		sources[key] = true
+		if pkg, ok := packages[dir]; ok && pkg != j.settings.Package {
+			return errorst.NewErrorWithCode(ExitUsage, "packages %s and %s are both generated into %s", pkg, j.settings.Package, dir)
+		}
+		packages[dir] = j.settings.Package
+	}
+	return nil
+}
+
+// checkPaths returns a usage error if rendered files of different
+// packages, or of a package, have the same path.
+func checkPaths(results [][]outputFile, groups []group) error {
+	seen := make(map[string]bool)
+	for i, files := range results {
+		if groups[i].settings().Output == stdio {
+			continue
+		}
+		for _, file := range files {
+			path := filepath.Clean(file.path)
+			if seen[path] {
+				return errorst.NewErrorWithCode(ExitUsage, "%s is generated more than once", path)
+			}
+			seen[path] = true
+		}
+	}
+	return nil
+}
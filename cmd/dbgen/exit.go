@@ -0,0 +1,57 @@
+package main
+
+import (
+	"dbgen/pkg/config"
+	"errors"
+	"github.com/thorn-jmh/errorst"
+)
+
+// exit codes by error class, attached to errors as error codes
+const (
+	ExitGenerate errorst.ErrorCode = 1 // failed to generate, or unclassified
+	ExitUsage    errorst.ErrorCode = 2 // invalid flags, arguments or config
+	ExitParse    errorst.ErrorCode = 3 // invalid schema or IR
+	ExitIO       errorst.ErrorCode = 4 // failed to read or write files
+	ExitStale    errorst.ErrorCode = 5 // generated files are stale, by --check
+	ExitLint     errorst.ErrorCode = 6 // schemas have lint errors, by lint
+)
+
+// exitCode returns the exit code of err. Errors joined by errors.Join
+// take the code of the first classified error.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if code := errorCode(err); code != errorst.NoCode {
+		return int(code)
+	}
+	return int(ExitGenerate)
+}
+
+// errorCode returns the first code of err and errors it wraps, depth first.
+func errorCode(err error) errorst.ErrorCode {
+	if err == nil {
+		return errorst.NoCode
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, inner := range joined.Unwrap() {
+			if code := errorCode(inner); code != errorst.NoCode {
+				return code
+			}
+		}
+		return errorst.NoCode
+	}
+	if code := errorst.GetCode(err); code != errorst.NoCode {
+		return code
+	}
+	return errorCode(errors.Unwrap(err))
+}
+
+// configError classifies errors of loading config, invalid
+// config is a usage error, others are I/O errors.
+func configError(err error) error {
+	if errors.Is(err, config.ErrInvalidConfig) {
+		return errorst.WrapWithCode(err, ExitUsage, "invalid config")
+	}
+	return errorst.WrapWithCode(err, ExitIO, "failed to load config")
+}
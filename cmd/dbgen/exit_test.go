@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/thorn-jmh/errorst"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	var (
+		plain        = errors.New("plain")
+		unclassified = errorst.NewError("unclassified")
+		parse        = errorst.NewErrorWithCode(ExitParse, "parse")
+		io           = errorst.WrapWithCode(plain, ExitIO, "io")
+	)
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"plain", plain, int(ExitGenerate)},
+		{"unclassified", unclassified, int(ExitGenerate)},
+		{"classified", parse, int(ExitParse)},
+		{"wrapped", errorst.Wrap(io, "wrapped"), int(ExitIO)},
+		{"wrapped by fmt", fmt.Errorf("wrapped: %w", io), int(ExitIO)},
+		{"joined", errors.Join(parse, io), int(ExitParse)},
+		{"joined after unclassified", errors.Join(unclassified, plain, io, parse), int(ExitIO)},
+		{"wrapped joined", errorst.Wrap(errors.Join(unclassified, io), "wrapped"), int(ExitIO)},
+		{"joined unclassified", errors.Join(unclassified, plain), int(ExitGenerate)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"dbgen/pkg/modelgen"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/thorn-jmh/errorst"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// formats of explanations
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+var explainFormat string
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [schema...]",
+	Short: "Print what each JSON Pointer of schemas became",
+	Long: "Print, for each JSON Pointer of schemas, the Go type, field, table and column " +
+		"it became, how it is stored (flattened column, inlined struct, sub relation, json) " +
+		"and which rules applied. Fields added by passes are listed at their owners.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if explainFormat != formatText && explainFormat != formatJSON {
+			return errorst.NewErrorWithCode(ExitUsage, "invalid format %s, must be %s or %s", explainFormat, formatText, formatJSON)
+		}
+		jobs, err := loadJobs(cmd, args)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return cmd.Help()
+		}
+		return explainAll(cmd.OutOrStdout(), jobs)
+	},
+}
+
+func init() {
+	explainCmd.Flags().StringVar(&explainFormat, "format", formatText, "output format, text or json")
+	rootCmd.AddCommand(explainCmd)
+}
+
+// schemaExplanation is explanations of a schema.
+type schemaExplanation struct {
+	Schema       string                 `json:"schema"`
+	Explanations []modelgen.Explanation `json:"explanations"`
+}
+
+// explainAll explains models of jobs by packages, models of a package
+// are combined first, so that renames on conflict are explained too.
+func explainAll(w io.Writer, jobs []job) error {
+	// first: load models in order of packages
+	groups := groupJobs(jobs)
+	jobs = nil
+	for _, g := range groups {
+		jobs = append(jobs, g.jobs...)
+	}
+	models := make([]*modelgen.Object, len(jobs))
+	err := runAll(jobs, func(i int, j job) error {
+		var err error
+		models[i], err = load(j)
+		return err
+	})
+
+	// second: combine packages and explain
+	var (
+		results []schemaExplanation
+		offset  int
+	)
+	for _, g := range groups {
+		groupModels := models[offset : offset+len(g.jobs)]
+		if allLoaded(groupModels) {
+			modelgen.NewPackage(groupModels)
+		}
+		for i, model := range groupModels {
+			if model != nil {
+				results = append(results, schemaExplanation{Schema: g.jobs[i].source(), Explanations: modelgen.Explain(model)})
+			}
+		}
+		offset += len(g.jobs)
+	}
+
+	if explainFormat == formatJSON {
+		data, jerr := json.MarshalIndent(results, "", "  ")
+		if jerr != nil {
+			return errors.Join(err, errorst.Wrap(jerr, "failed to marshal explanations"))
+		}
+		_, _ = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+	for _, result := range results {
+		writeExplanations(w, result)
+	}
+	return err
+}
+
+// writeExplanations prints explanations of a schema as a table.
+func writeExplanations(w io.Writer, result schemaExplanation) {
+	_, _ = fmt.Fprintf(w, "%s\n", result.Schema)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "POINTER\tKIND\tGO\tTABLE\tCOLUMN\tRULES")
+	for _, ex := range result.Explanations {
+		goName := ex.Type
+		if ex.Field != "" {
+			goName += "." + ex.Field
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			ex.Pointer, ex.Kind, goName, orDash(ex.Table), orDash(ex.Column), strings.Join(ex.Rules, "; "))
+	}
+	_ = tw.Flush()
+	_, _ = fmt.Fprintln(w)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -1,34 +1,377 @@
 package main
 
 import (
+	"bytes"
+	"dbgen/pkg/config"
 	"dbgen/pkg/modelgen"
 	"dbgen/pkg/schemas"
-	"github.com/dave/jennifer/jen"
+	"errors"
+	"fmt"
 	"github.com/thorn-jmh/errorst"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-func gen(schemaPath string) error {
+// stdio as schema path reads stdin, and as output directory writes stdout.
+const stdio = "-"
 
-	// first parse the schema
-	jsch, err := schemas.FromJSONFile(schemaPath)
-	if err != nil {
-		return errorst.Wrap(err, "failed to parse schema file %s", schemaPath)
+// outputFile is a generated file in memory.
+type outputFile struct {
+	path    string
+	content []byte
+}
+
+// group is jobs generated into the same output package.
+type group struct {
+	jobs []job
+}
+
+// settings are settings of the package, from its first job.
+func (g group) settings() config.Settings {
+	return g.jobs[0].settings
+}
+
+// groupJobs groups jobs by output directory and package name,
+// in order of their first jobs.
+func groupJobs(jobs []job) []group {
+	var (
+		groups  []group
+		indexes = make(map[[2]string]int)
+	)
+	for _, j := range jobs {
+		key := [2]string{filepath.Clean(j.settings.Output), j.settings.Package}
+		if i, ok := indexes[key]; ok {
+			groups[i].jobs = append(groups[i].jobs, j)
+			continue
+		}
+		indexes[key] = len(groups)
+		groups = append(groups, group{jobs: []job{j}})
 	}
+	return groups
+}
 
-	// then generate the code
-	model, err := modelgen.GenAndProcess(jsch)
-	if err != nil {
+// runAll runs fn on jobs in parallel by at most workers, errors
+// are joined in the order of jobs. With failFast, no job is
+// started after the first failure.
+func runAll(jobs []job, fn func(i int, j job) error) error {
+	var (
+		errs   = make([]error, len(jobs))
+		sem    = make(chan struct{}, max(workers, 1))
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+	for i, j := range jobs {
+		sem <- struct{}{}
+		if failFast && failed.Load() {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int, j job) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if errs[i] = fn(i, j); errs[i] != nil {
+				failed.Store(true)
+			}
+		}(i, j)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// genAll generates and writes files of jobs. Packages whose inputs are
+// unchanged since last generation are skipped, unless force is set.
+// Files to stdout, and files listed by dryRun, are printed to w in
+// order of jobs.
+func genAll(w io.Writer, jobs []job) error {
+	var (
+		c      = loadCache(jobs)
+		groups []group
+		hashes []string
+	)
+
+	// first: skip unchanged packages, stdin and stdout are not cached
+	for _, g := range groupJobs(jobs) {
+		var hash string
+		if g.cacheable() {
+			var err error
+			if hash, err = groupHash(g); err != nil {
+				return err
+			}
+			if !force && c.fresh(g, hash) {
+				continue
+			}
+		}
+		groups = append(groups, g)
+		hashes = append(hashes, hash)
+	}
+
+	// second: render and write
+	results, err := renderGroups(groups)
+	if perr := checkPaths(results, groups); perr != nil {
+		return errors.Join(err, perr)
+	}
+	for i, files := range results {
+		s := groups[i].settings()
+		switch {
+		case files == nil:
+		case dryRun:
+			for _, file := range files {
+				if s.Output == stdio {
+					_, _ = fmt.Fprintf(w, "%s (%d bytes, to stdout)\n", file.path, len(file.content))
+				} else {
+					_, _ = fmt.Fprintf(w, "%s (%d bytes)\n", file.path, len(file.content))
+				}
+			}
+		case s.Output == stdio:
+			for _, file := range files {
+				_, _ = w.Write(file.content)
+			}
+		default:
+			if werr := writeFiles(files); werr != nil {
+				err = errors.Join(err, werr)
+				continue
+			}
+			if groups[i].cacheable() {
+				c.store(groups[i], hashes[i], files)
+			}
+		}
+	}
+	if dryRun {
 		return err
 	}
+	return errors.Join(err, c.save())
+}
 
-	// then write the code
-	fp := jen.NewFile(packageName)
-	fp.HeaderComment("Code generated by dbgen. DO NOT EDIT.")
-	if err := model.Gen(fp); err != nil {
+func gen(g group) error {
+	results, err := renderGroups([]group{g})
+	if err != nil {
 		return err
 	}
-	if err := fp.Save(outputDir + "/" + model.Name + ".go"); err != nil {
-		return errorst.Wrap(err, "failed to save file")
+	return writeFiles(results[0])
+}
+
+func writeFiles(files []outputFile) error {
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.path), 0755); err != nil {
+			return errorst.WrapWithCode(err, ExitIO, "failed to create directory of %s", file.path)
+		}
+		if err := os.WriteFile(file.path, file.content, 0644); err != nil {
+			return errorst.WrapWithCode(err, ExitIO, "failed to save file %s", file.path)
+		}
 	}
 	return nil
 }
+
+// renderGroups loads models of all jobs in parallel, then renders files
+// of each group in memory. Files of failed groups are nil.
+func renderGroups(groups []group) ([][]outputFile, error) {
+	// first: load models
+	var jobs []job
+	for _, g := range groups {
+		jobs = append(jobs, g.jobs...)
+	}
+	models := make([]*modelgen.Object, len(jobs))
+	err := runAll(jobs, func(i int, j job) error {
+		var err error
+		models[i], err = load(j)
+		return err
+	})
+
+	// second: render groups with all models loaded
+	var (
+		results = make([][]outputFile, len(groups))
+		offset  int
+	)
+	for i, g := range groups {
+		groupModels := models[offset : offset+len(g.jobs)]
+		offset += len(g.jobs)
+		if !allLoaded(groupModels) || failFast && err != nil {
+			continue
+		}
+
+		files, rerr := renderGroup(g, groupModels)
+		if rerr != nil {
+			err = errors.Join(err, rerr)
+			continue
+		}
+		results[i] = files
+	}
+	return results, err
+}
+
+// renderGroup renders files of a package. Go files are shared by models,
+// files of templates and ddl are rendered for each model.
+func renderGroup(g group, models []*modelgen.Object) ([]outputFile, error) {
+	var (
+		files []outputFile
+		s     = g.settings()
+	)
+	if s.HasBackend(config.BackendGo) {
+		pkg := modelgen.NewPackage(models)
+		for _, file := range pkg.Files(s.IsSplit()) {
+			code, err := g.jobs[0].generator.RenderFile(file)
+			if err != nil {
+				return nil, errorst.WrapWithCode(err, ExitGenerate, "failed to render %s", file.Name)
+			}
+			files = append(files, outputFile{outputPath(s, file.Name), code})
+		}
+	}
+
+	for i, j := range g.jobs {
+		if j.settings.HasBackend(config.BackendTemplate) {
+			tmplFiles, err := renderTemplates(j, models[i])
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, tmplFiles...)
+		}
+		if j.settings.HasBackend(config.BackendDDL) {
+			files = append(files, outputFile{outputPath(j.settings, models[i].Name+".sql"), modelgen.GenDDL(models[i])})
+		}
+	}
+	return files, nil
+}
+
+func renderTemplates(j job, model *modelgen.Object) ([]outputFile, error) {
+	tmpls, err := modelgen.LoadTemplates(j.settings.Templates, modelgen.TemplateFuncs(j.generator.Options.Naming))
+	if err != nil {
+		return nil, errorst.WrapWithCode(err, ExitUsage, "failed to load templates")
+	}
+	tmplFiles, err := tmpls.Execute(modelgen.NewTemplateData(j.settings.Package, model))
+	if err != nil {
+		return nil, errorst.WrapWithCode(err, ExitGenerate, "failed to render templates of %s", j.source())
+	}
+
+	files := make([]outputFile, 0, len(tmplFiles))
+	for _, file := range tmplFiles {
+		files = append(files, outputFile{outputPath(j.settings, file.Name), file.Content})
+	}
+	return files, nil
+}
+
+func allLoaded(models []*modelgen.Object) bool {
+	for _, model := range models {
+		if model == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// outputPath is the path of output file name,
+// or just the name if output is stdout.
+func outputPath(s config.Settings, name string) string {
+	if s.Output == stdio {
+		return name
+	}
+	return filepath.Join(s.Output, name)
+}
+
+// load returns the processed model of schema file, or of IR
+// file if fromIR is set. Schema path stdio reads stdin.
+func load(j job) (*modelgen.Object, error) {
+	data, err := readInput(j)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromIR {
+		model, err := modelgen.UnmarshalIR(data)
+		if err != nil {
+			return nil, errorst.WrapWithCode(err, ExitParse, "failed to parse IR file %s", j.schema)
+		}
+		return model, nil
+	}
+
+	// references to other documents are not resolved by the generator
+	refs, err := schemas.ExternalRefs(data)
+	if err != nil {
+		return nil, errorst.WrapWithCode(err, ExitParse, "failed to parse schema file %s", j.source())
+	}
+	if len(refs) > 0 {
+		return nil, errorst.NewErrorWithCode(ExitUsage, "%s refers to other documents by $ref %s, which is not supported", j.source(), strings.Join(refs, ", "))
+	}
+
+	var jsch *schemas.Schema
+	if j.component != "" {
+		var doc *schemas.OpenAPI
+		if doc, err = parseOpenAPI(j, data); err == nil {
+			jsch, err = doc.FromComponent(j.component, j.declared...)
+		}
+	} else {
+		jsch, err = schemas.FromJSON(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, errorst.WrapWithCode(err, ExitParse, "failed to parse schema file %s", j.source())
+	}
+	model, err := j.generator.Generate(jsch)
+	if err != nil {
+		return nil, errorst.WrapWithCode(err, ExitGenerate, "failed to generate %s", j.source())
+	}
+	return model, nil
+}
+
+// openAPIs are parsed OpenAPI documents by path, so that jobs of their
+// components decode each once, until the data is changed.
+var openAPIs struct {
+	sync.Mutex
+	docs map[string]openAPI
+}
+
+type openAPI struct {
+	data []byte
+	doc  *schemas.OpenAPI
+}
+
+// parseOpenAPI parses data of OpenAPI document j.schema, or returns it
+// parsed before if data is the same.
+func parseOpenAPI(j job, data []byte) (*schemas.OpenAPI, error) {
+	openAPIs.Lock()
+	defer openAPIs.Unlock()
+	if parsed, ok := openAPIs.docs[j.schema]; ok && bytes.Equal(parsed.data, data) {
+		return parsed.doc, nil
+	}
+	doc, err := schemas.ParseOpenAPI(data)
+	if err != nil {
+		return nil, err
+	}
+	if openAPIs.docs == nil {
+		openAPIs.docs = make(map[string]openAPI)
+	}
+	openAPIs.docs[j.schema] = openAPI{data: data, doc: doc}
+	return doc, nil
+}
+
+// stdin is read once, as jobs of an OpenAPI document share it.
+var stdin struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// readInput reads the schema file of j, or stdin if it is stdio.
+func readInput(j job) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if j.schema == stdio {
+		stdin.once.Do(func() {
+			stdin.data, stdin.err = io.ReadAll(os.Stdin)
+		})
+		data, err = stdin.data, stdin.err
+	} else {
+		data, err = os.ReadFile(j.schema)
+	}
+	if err != nil {
+		return nil, errorst.WrapWithCode(err, ExitIO, "failed to read %s", j.schema)
+	}
+	return data, nil
+}
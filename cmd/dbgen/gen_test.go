@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"dbgen/pkg/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testJob(schema, component, output, pkg string) job {
+	return job{
+		schema:    schema,
+		component: component,
+		settings:  config.Settings{Output: output, Package: pkg},
+	}
+}
+
+func TestCheckOutputs(t *testing.T) {
+	tests := []struct {
+		name string
+		jobs []job
+		ok   bool
+	}{
+		{
+			name: "distinct schemas",
+			jobs: []job{testJob("a.json", "", "model", "model"), testJob("b.json", "", "model", "model")},
+			ok:   true,
+		},
+		{
+			name: "same schema into distinct directories",
+			jobs: []job{testJob("a.json", "", "model", "model"), testJob("a.json", "", "other", "other")},
+			ok:   true,
+		},
+		{
+			name: "components of a document",
+			jobs: []job{testJob("api.yaml", "Pet", "model", "model"), testJob("api.yaml", "Owner", "model", "model")},
+			ok:   true,
+		},
+		{
+			name: "same schema twice",
+			jobs: []job{testJob("a.json", "", "model", "model"), testJob("a.json", "", "./model/", "model")},
+		},
+		{
+			name: "same component twice",
+			jobs: []job{testJob("api.yaml", "Pet", "model", "model"), testJob("api.yaml", "Pet", "model", "model")},
+		},
+		{
+			name: "packages in a directory",
+			jobs: []job{testJob("a.json", "", "model", "model"), testJob("b.json", "", "model", "other")},
+		},
+		{
+			name: "stdout",
+			jobs: []job{testJob("a.json", "", stdio, "model"), testJob("a.json", "", stdio, "other")},
+			ok:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOutputs(tt.jobs)
+			if (err == nil) != tt.ok {
+				t.Fatalf("checkOutputs() = %v, want ok %v", err, tt.ok)
+			}
+			if err != nil && exitCode(err) != int(ExitUsage) {
+				t.Errorf("exit code = %d, want %d", exitCode(err), ExitUsage)
+			}
+		})
+	}
+}
+
+func TestCheckPaths(t *testing.T) {
+	groups := []group{
+		{jobs: []job{testJob("a.json", "", "model", "model")}},
+		{jobs: []job{testJob("b.json", "", "model/", "model")}},
+	}
+	ok := [][]outputFile{{{path: "model/a.go"}}, {{path: "model/b.go"}}}
+	if err := checkPaths(ok, groups); err != nil {
+		t.Errorf("checkPaths() = %v, want nil", err)
+	}
+	collided := [][]outputFile{{{path: "model/a.go"}}, {{path: "model/./a.go"}}}
+	if err := checkPaths(collided, groups); err == nil {
+		t.Errorf("checkPaths() = nil, want error")
+	}
+}
+
+const testOpenAPI = `openapi: 3.1.0
+info: {title: pets, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string}
+        owner: {$ref: '#/components/schemas/Owner'}
+    Owner:
+      type: object
+      properties:
+        pets: {type: array, items: {$ref: '#/components/schemas/Pet'}}
+`
+
+func TestGenOpenAPI(t *testing.T) {
+	dir := t.TempDir()
+	jobs, err := expandComponents([]job{generatorJob(t, dir, "pets.yaml", testOpenAPI)})
+	if err != nil {
+		t.Fatalf("expandComponents() = %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expandComponents() = %d jobs, want 2", len(jobs))
+	}
+	if err := genAll(&bytes.Buffer{}, jobs); err != nil {
+		t.Fatalf("genAll() = %v", err)
+	}
+
+	// components refer to each other by the types declared once
+	files, err := filepath.Glob(filepath.Join(dir, "model", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var code strings.Builder
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code.Write(data)
+	}
+	for _, want := range []string{"type Pet struct", "type Owner struct", "*Owner", "[]Pet"} {
+		if strings.Count(code.String(), want) != 1 {
+			t.Errorf("generated code has %q %d times, want once:\n%s", want, strings.Count(code.String(), want), code.String())
+		}
+	}
+	for _, dup := range []string{"PetOwner", "OwnerPetsItem"} {
+		if strings.Contains(code.String(), dup) {
+			t.Errorf("generated code declares %s again:\n%s", dup, code.String())
+		}
+	}
+}
+
+func TestGenAllPathErrors(t *testing.T) {
+	dir := t.TempDir()
+	model := generatorJob(t, dir, "order.json", testSchema)
+	other := model
+	other.settings.Package = "other"
+	broken := generatorJob(t, dir, "broken.json", `{"$id": "Broken", "type": "object", "properties": {"a": {"type": "array"}}}`)
+	broken.settings.Output = filepath.Join(dir, "broken")
+
+	err := genAll(&bytes.Buffer{}, []job{model, other, broken})
+	if err == nil {
+		t.Fatal("genAll() = nil, want error")
+	}
+	// errors of loading are kept with the collision
+	for _, want := range []string{"generated more than once", "array without items"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("genAll() = %v, want %s", err, want)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "model", "*.go")); len(files) > 0 {
+		t.Errorf("genAll() wrote %v", files)
+	}
+}
+
+// setStdin makes data the stdin of jobs until the test ends.
+func setStdin(t *testing.T, data string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdin
+	os.Stdin = f
+	stdin.once = sync.Once{}
+	t.Cleanup(func() {
+		os.Stdin = saved
+		stdin.once = sync.Once{}
+		_ = f.Close()
+	})
+}
+
+func TestGenAllStdio(t *testing.T) {
+	tests := []struct {
+		name           string
+		dryRun         bool
+		stdin, stdout  bool
+		printed, files []string // substrings printed, and files written to output
+	}{
+		{
+			name:  "files",
+			files: []string{CacheFile, "Order.go"},
+		},
+		{
+			name:    "dry run",
+			dryRun:  true,
+			printed: []string{filepath.Join("model", "Order.go") + " ("},
+		},
+		{
+			name:    "dry run to stdout",
+			dryRun:  true,
+			stdout:  true,
+			printed: []string{"Order.go (", "bytes, to stdout)"},
+		},
+		{
+			name:    "stdout",
+			stdout:  true,
+			printed: []string{"type Order struct", "type OrderItemsItem struct"},
+		},
+		{
+			name:  "stdin",
+			stdin: true,
+			files: []string{"Order.go"},
+		},
+		{
+			name:    "stdin to stdout",
+			stdin:   true,
+			stdout:  true,
+			printed: []string{"type Order struct"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			j := generatorJob(t, dir, "order.json", testSchema)
+			if tt.stdin {
+				setStdin(t, testSchema)
+				j.schema = stdio
+			}
+			if tt.stdout {
+				j.settings.Output = stdio
+			}
+			saved := dryRun
+			dryRun = tt.dryRun
+			defer func() { dryRun = saved }()
+
+			var w bytes.Buffer
+			if err := genAll(&w, []job{j}); err != nil {
+				t.Fatalf("genAll() = %v", err)
+			}
+			for _, want := range tt.printed {
+				if !strings.Contains(w.String(), want) {
+					t.Errorf("printed %q, want %q", w.String(), want)
+				}
+			}
+			if len(tt.printed) == 0 && w.Len() > 0 {
+				t.Errorf("printed %q, want nothing", w.String())
+			}
+
+			var files []string
+			entries, _ := os.ReadDir(filepath.Join(dir, "model"))
+			for _, entry := range entries {
+				files = append(files, entry.Name())
+			}
+			if strings.Join(files, ",") != strings.Join(tt.files, ",") {
+				t.Errorf("files = %v, want %v", files, tt.files)
+			}
+		})
+	}
+}
+
+func TestLoadExternalRef(t *testing.T) {
+	dir := t.TempDir()
+	j := generatorJob(t, dir, "order.json", `{"$id": "Order", "type": "object", "properties": {"owner": {"$ref": "common.json#/$defs/owner"}}}`)
+	_, err := load(j)
+	if err == nil || !strings.Contains(err.Error(), "refers to other documents by $ref common.json#/$defs/owner") {
+		t.Fatalf("load() = %v, want external $ref error", err)
+	}
+	if exitCode(err) != int(ExitUsage) {
+		t.Errorf("exit code = %d, want %d", exitCode(err), ExitUsage)
+	}
+}
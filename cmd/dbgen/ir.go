@@ -0,0 +1,51 @@
+package main
+
+import (
+	"dbgen/pkg/modelgen"
+	"errors"
+	"github.com/spf13/cobra"
+	"io"
+)
+
+var irCmd = &cobra.Command{
+	Use:   "ir [schema...]",
+	Short: "Print the processed model of schemas as JSON",
+	Long: "Print the processed model of schemas as JSON, which can be " +
+		"post-processed and fed back by `dbgen --ir <file...>`",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobs, err := loadJobs(cmd, args)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return cmd.Help()
+		}
+
+		return irAll(cmd.OutOrStdout(), jobs)
+	},
+}
+
+// irAll writes the IR of each job to w.
+func irAll(w io.Writer, jobs []job) error {
+	var errs []error
+	for _, j := range jobs {
+		model, err := load(j)
+		if err == nil {
+			var data []byte
+			if data, err = modelgen.MarshalIR(model); err == nil {
+				_, err = w.Write(data)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			if failFast {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func init() {
+	rootCmd.AddCommand(irCmd)
+}
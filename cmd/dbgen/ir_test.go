@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"dbgen/pkg/config"
+	"dbgen/pkg/modelgen"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIRAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.json")
+	schema := `{
+	"$id": "Order",
+	"type": "object",
+	"properties": {
+		"no": {"type": "string"},
+		"items": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "string"}}}}
+	}
+}`
+	if err := os.WriteFile(path, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings := config.DefaultSettings()
+	j := job{schema: path, settings: settings, generator: modelgen.NewGenerator(settings.Package, nil)}
+
+	var w bytes.Buffer
+	if err := irAll(&w, []job{j}); err != nil {
+		t.Fatalf("irAll() = %v", err)
+	}
+	model, err := modelgen.UnmarshalIR(w.Bytes())
+	if err != nil {
+		t.Fatalf("UnmarshalIR() = %v", err)
+	}
+	if model.Name != "Order" || len(model.SubRelations) != 1 {
+		t.Errorf("IR of %s with %d sub relations, want Order with 1", model.Name, len(model.SubRelations))
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"dbgen/pkg/lint"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/thorn-jmh/errorst"
+	"io"
+)
+
+const formatSARIF = "sarif"
+
+var (
+	lintFormat string
+	lintStrict bool
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [schema...]",
+	Short: "Warn about schema constructs dbgen can not represent",
+	Long: "Check schemas before generation, for constructs dbgen can not represent or " +
+		"represents lossily: unsupported and unknown keywords, unsatisfiable required, " +
+		"enums of mixed types, keywords beside $ref, missing type, arrays without items, " +
+		"and property names collapsing to the same Go field. Exits with 6 if any error is found, " +
+		"or any warning with --strict.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if lintFormat != formatText && lintFormat != formatJSON && lintFormat != formatSARIF {
+			return errorst.NewErrorWithCode(ExitUsage, "invalid format %s, must be %s, %s or %s", lintFormat, formatText, formatJSON, formatSARIF)
+		}
+		jobs, err := loadJobs(cmd, args)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return cmd.Help()
+		}
+		return lintAll(cmd.OutOrStdout(), jobs)
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVar(&lintFormat, "format", formatText, "output format, text, json or sarif")
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "fail on warnings too")
+	rootCmd.AddCommand(lintCmd)
+}
+
+// lintAll lints schemas of jobs, and prints diagnostics to w in order of jobs.
+func lintAll(w io.Writer, jobs []job) error {
+	// first: lint schemas
+	files := make([]lint.FileDiagnostics, len(jobs))
+	err := runAll(jobs, func(i int, j job) error {
+		data, err := readInput(j)
+		if err != nil {
+			return err
+		}
+		var diags []lint.Diagnostic
+		if j.component != "" {
+			diags, err = lint.LintComponent(data, j.component, j.generator.Options.Naming.Fields)
+		} else {
+			diags, err = lint.Lint(data, j.generator.Options.Naming.Fields)
+		}
+		if err != nil {
+			return errorst.WrapWithCode(err, ExitParse, "failed to parse schema file %s", j.source())
+		}
+		files[i] = lint.FileDiagnostics{File: j.schema, Diagnostics: diags}
+		return nil
+	})
+
+	// second: print diagnostics
+	var failed int
+	for _, file := range files {
+		for _, diag := range file.Diagnostics {
+			if diag.Severity == lint.SeverityError || lintStrict {
+				failed++
+			}
+		}
+	}
+	switch lintFormat {
+	case formatJSON:
+		data, jerr := json.MarshalIndent(files, "", "  ")
+		if jerr != nil {
+			return errors.Join(err, errorst.Wrap(jerr, "failed to marshal diagnostics"))
+		}
+		_, _ = fmt.Fprintf(w, "%s\n", data)
+	case formatSARIF:
+		data, serr := lint.SARIF(files)
+		if serr != nil {
+			return errors.Join(err, serr)
+		}
+		_, _ = fmt.Fprintf(w, "%s\n", data)
+	default:
+		for _, file := range files {
+			for _, diag := range file.Diagnostics {
+				_, _ = fmt.Fprintf(w, "%s:%d: %s: %s (%s at %s)\n", file.File, diag.Line, diag.Severity, diag.Message, diag.Rule, diag.Pointer)
+			}
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errorst.NewErrorWithCode(ExitLint, "found %d lint errors", failed)
+	}
+	return nil
+}
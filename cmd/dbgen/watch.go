@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	pollInterval time.Duration
+	debounce     time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [schema...]",
+	Short: "Regenerate when schemas change",
+	Long: "Watch schemas by polling, and regenerate outputs of changed schemas. " +
+		"Schemas referring to other documents by $ref are not supported. Stop it by Ctrl-C.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobs, err := loadJobs(cmd, args)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return cmd.Help()
+		}
+		if err := checkNoStdio(jobs, true); err != nil {
+			return err
+		}
+		if err := checkOutputs(jobs); err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return newWatcher(cmd.OutOrStdout(), jobs).run(ctx)
+	},
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&pollInterval, "interval", 300*time.Millisecond, "interval of polling files")
+	watchCmd.Flags().DurationVar(&debounce, "debounce", 200*time.Millisecond, "wait until files are unchanged for this long before regenerating")
+	rootCmd.AddCommand(watchCmd)
+}
+
+// fileStamp identifies a version of file, zero if file does not exist.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watcher polls files of packages, and regenerates packages depending
+// on changed files.
+type watcher struct {
+	out    io.Writer
+	groups []group
+	deps   [][]string           // schema files of each package
+	stamps map[string]fileStamp // last seen stamps of watched files
+}
+
+func newWatcher(out io.Writer, jobs []job) *watcher {
+	w := &watcher{
+		out:    out,
+		groups: groupJobs(jobs),
+		stamps: make(map[string]fileStamp),
+	}
+	for _, g := range w.groups {
+		var deps []string
+		for _, j := range g.jobs {
+			dep := filepath.Clean(j.schema)
+			deps = append(deps, dep)
+			w.stamps[dep] = statFile(dep)
+		}
+		w.deps = append(w.deps, deps)
+	}
+	return w
+}
+
+func (w *watcher) run(ctx context.Context) error {
+	// first: generate all
+	all := make([]int, len(w.groups))
+	for i := range all {
+		all[i] = i
+	}
+	w.regenerate(all)
+	_, _ = fmt.Fprintf(w.out, "watching %d files of %d packages, press Ctrl-C to stop\n", len(w.stamps), len(w.groups))
+
+	// second: poll until stopped
+	var (
+		ticker     = time.NewTicker(pollInterval)
+		pending    = make(map[string]bool)
+		lastChange time.Time
+	)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if changed := w.poll(); len(changed) > 0 {
+				for _, file := range changed {
+					pending[file] = true
+				}
+				lastChange = now
+			}
+			if len(pending) == 0 || now.Sub(lastChange) < debounce {
+				continue
+			}
+
+			w.regenerate(w.affected(pending))
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// poll returns watched files changed since last poll.
+func (w *watcher) poll() []string {
+	var changed []string
+	for file, old := range w.stamps {
+		if stamp := statFile(file); stamp != old {
+			w.stamps[file] = stamp
+			changed = append(changed, file)
+		}
+	}
+	return changed
+}
+
+// affected returns indexes of packages depending on any of files.
+func (w *watcher) affected(files map[string]bool) []int {
+	var indexes []int
+	for i, deps := range w.deps {
+		for _, dep := range deps {
+			if files[dep] {
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// regenerate generates packages of indexes.
+func (w *watcher) regenerate(indexes []int) {
+	for _, i := range indexes {
+		g := w.groups[i]
+		start := time.Now()
+
+		err := gen(g)
+		name := g.settings().Output
+		if err != nil {
+			_, _ = fmt.Fprintf(w.out, "%s %s: %s\n", time.Now().Format(time.TimeOnly), name, briefError(err))
+			continue
+		}
+		_, _ = fmt.Fprintf(w.out, "%s %s: generated %d schemas in %s\n", time.Now().Format(time.TimeOnly), name, len(g.jobs), time.Since(start).Round(time.Millisecond))
+	}
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// briefError renders messages of err chain in one line, without traces.
+func briefError(err error) string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var msgs []string
+		for _, e := range joined.Unwrap() {
+			msgs = append(msgs, briefError(e))
+		}
+		return strings.Join(msgs, "; ")
+	}
+
+	var msgs []string
+	for err != nil {
+		msg := fmt.Sprintf("%s", err)
+		msgs = append(msgs, msg)
+		// errors of errorst only print their own message by %s,
+		// other errors already include their causes.
+		if msg == fmt.Sprintf("%v", err) {
+			break
+		}
+		err = errors.Unwrap(err)
+	}
+	return strings.Join(msgs, ": ")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWatcherAffected(t *testing.T) {
+	var (
+		dirA = t.TempDir()
+		dirB = t.TempDir()
+		ref  = filepath.Join(dirA, "common.json")
+	)
+	if err := os.WriteFile(ref, []byte(`{"type": "object", "properties": {"name": {"type": "string"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jobs := []job{
+		generatorJob(t, dirA, "order.json", `{"$id": "Order", "type": "object", "properties": {"owner": {"$ref": "common.json"}}}`),
+		generatorJob(t, dirB, "item.json", strings.Replace(testSchema, "Order", "Item", 1)),
+	}
+
+	var out bytes.Buffer
+	w := newWatcher(&out, jobs)
+	// schemas are watched, files they reference are not supported
+	w.regenerate([]int{0, 1})
+	if want := []string{jobs[0].schema}; !reflect.DeepEqual(w.deps[0], want) {
+		t.Fatalf("dependencies = %v, want %v", w.deps[0], want)
+	}
+	if !strings.Contains(out.String(), "refers to other documents by $ref common.json") {
+		t.Errorf("regenerate printed:\n%s", out.String())
+	}
+	if changed := w.poll(); len(changed) > 0 {
+		t.Fatalf("poll() = %v right after regenerate", changed)
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want []int
+	}{
+		{"referenced file", ref, nil},
+		{"schema", jobs[0].schema, []int{0}},
+		{"other schema", jobs[1].schema, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.OpenFile(tt.file, os.O_APPEND|os.O_WRONLY, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = f.WriteString("\n")
+			_ = f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pending := make(map[string]bool)
+			for _, file := range w.poll() {
+				pending[file] = true
+			}
+			if got := w.affected(pending); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("affected(%v) = %v, want %v", pending, got, tt.want)
+			}
+		})
+	}
+}
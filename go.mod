@@ -5,9 +5,12 @@ go 1.21.4
 require (
 	entgo.io/ent v0.12.5
 	github.com/dave/jennifer v1.7.0
+	github.com/pmezard/go-difflib v1.0.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/sirupsen/logrus v1.9.3
 	github.com/spf13/cobra v1.7.0
 	github.com/thorn-jmh/errorst v0.1.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
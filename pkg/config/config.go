@@ -0,0 +1,267 @@
+package config
+
+import (
+	"bytes"
+	"dbgen/pkg/schemas"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/thorn-jmh/errorst"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileName is the name of config file, searched upward from working directory.
+const FileName = "dbgen.yaml"
+
+// JSONSchema is the JSON Schema of config file, which configs are validated against.
+//
+//go:embed dbgen.schema.json
+var JSONSchema []byte
+
+// configSchema is JSONSchema compiled once.
+var configSchema struct {
+	once   sync.Once
+	schema *jsonschema.Schema
+	err    error
+}
+
+var ErrInvalidConfig = errorst.NewError("invalid config")
+
+// Config is the content of config file.
+type Config struct {
+	Settings  `yaml:",inline"`
+	Inputs    []Input    `yaml:"inputs"`    // schemas to generate
+	Overrides []Override `yaml:"overrides"` // settings of specific schemas
+
+	Dir string `yaml:"-"` // directory of config file, relative paths are resolved against it
+}
+
+// Input is a group of schemas sharing settings.
+type Input struct {
+	Schemas  []string `yaml:"schemas"` // glob patterns of schema files
+	Settings `yaml:",inline"`
+}
+
+// Override overrides settings of schemas matching Schema.
+type Override struct {
+	Schema   string `yaml:"schema"` // glob pattern of schema files
+	Settings `yaml:",inline"`
+}
+
+// Job is a schema file with its resolved settings.
+type Job struct {
+	Schema string
+	Settings
+}
+
+// Find searches FileName from dir upward, and returns "" if not found.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errorst.Wrap(err, "failed to get absolute path of %s", dir)
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", errorst.Wrap(err, "failed to stat %s", path)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads and validates config file at path.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to open config %s", path)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to get absolute path of %s", path)
+	}
+	cfg, err := Parse(f, dir)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to load config %s", path)
+	}
+	return cfg, nil
+}
+
+// Parse reads config from r, relative paths are resolved against dir.
+func Parse(r io.Reader, dir string) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to read config")
+	}
+	if err := Validate(data); err != nil {
+		return nil, err
+	}
+	cfg := &Config{Dir: dir}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errorst.Wrap(ErrInvalidConfig, "%v", err)
+	}
+
+	// first: check settings
+	if err := cfg.Settings.check(); err != nil {
+		return nil, err
+	}
+	for i := range cfg.Inputs {
+		if len(cfg.Inputs[i].Schemas) == 0 {
+			return nil, errorst.Wrap(ErrInvalidConfig, "input %d has no schemas", i)
+		}
+		if err := cfg.Inputs[i].Settings.check(); err != nil {
+			return nil, errorst.Wrap(err, "invalid input %d", i)
+		}
+	}
+	for i := range cfg.Overrides {
+		if cfg.Overrides[i].Schema == "" {
+			return nil, errorst.Wrap(ErrInvalidConfig, "override %d has no schema", i)
+		}
+		if err := cfg.Overrides[i].Settings.check(); err != nil {
+			return nil, errorst.Wrap(err, "invalid override of %s", cfg.Overrides[i].Schema)
+		}
+	}
+
+	// second: resolve relative paths
+	cfg.Settings.resolvePaths(dir)
+	for i := range cfg.Inputs {
+		cfg.Inputs[i].Settings.resolvePaths(dir)
+		for j, pattern := range cfg.Inputs[i].Schemas {
+			cfg.Inputs[i].Schemas[j] = resolvePath(dir, pattern)
+		}
+	}
+	for i := range cfg.Overrides {
+		cfg.Overrides[i].Settings.resolvePaths(dir)
+		cfg.Overrides[i].Schema = resolvePath(dir, cfg.Overrides[i].Schema)
+	}
+	return cfg, nil
+}
+
+// Validate validates the config document data against JSONSchema,
+// each problem is reported with the JSON Pointer of the offending value.
+func Validate(data []byte) error {
+	// first: yaml to JSON values
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return errorst.Wrap(ErrInvalidConfig, "%v", err)
+	}
+	if doc == nil {
+		return nil
+	}
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return errorst.Wrap(ErrInvalidConfig, "%v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return errorst.Wrap(ErrInvalidConfig, "%v", err)
+	}
+
+	// second: validate against JSONSchema
+	configSchema.once.Do(func() {
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource(FileName, bytes.NewReader(JSONSchema)); err != nil {
+			configSchema.err = err
+			return
+		}
+		configSchema.schema, configSchema.err = compiler.Compile(FileName)
+	})
+	if configSchema.err != nil {
+		return errorst.Wrap(configSchema.err, "failed to compile JSON Schema of config")
+	}
+	if err := configSchema.schema.Validate(doc); err != nil {
+		var verr *jsonschema.ValidationError
+		if !errors.As(err, &verr) {
+			return errorst.Wrap(err, "failed to validate config")
+		}
+		problems := schemas.ValidationProblems(verr)
+		return errorst.Wrap(ErrInvalidConfig, "%d problems against JSON Schema of config:\n  %s", len(problems), strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Jobs expands schema patterns of inputs, each schema appears once.
+func (c *Config) Jobs() ([]Job, error) {
+	var (
+		jobs []Job
+		seen = make(map[string]bool)
+	)
+	for _, input := range c.Inputs {
+		for _, pattern := range input.Schemas {
+			paths, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, errorst.Wrap(ErrInvalidConfig, "invalid schema pattern %s: %v", pattern, err)
+			}
+			if len(paths) == 0 {
+				return nil, errorst.Wrap(ErrInvalidConfig, "no schema matches %s", pattern)
+			}
+			sort.Strings(paths)
+			for _, path := range paths {
+				if seen[path] {
+					continue
+				}
+				seen[path] = true
+				jobs = append(jobs, Job{Schema: path, Settings: c.Resolve(path)})
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// Resolve returns settings of schema at path: top level settings,
+// overridden by inputs and then overrides matching path in order.
+func (c *Config) Resolve(path string) Settings {
+	settings := DefaultSettings()
+	settings.Merge(c.Settings)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	for _, input := range c.Inputs {
+		for _, pattern := range input.Schemas {
+			if match(pattern, path) {
+				settings.Merge(input.Settings)
+				break
+			}
+		}
+	}
+	for _, override := range c.Overrides {
+		if match(override.Schema, path) {
+			settings.Merge(override.Settings)
+		}
+	}
+	return settings
+}
+
+func match(pattern string, path string) bool {
+	ok, err := filepath.Match(pattern, path)
+	return err == nil && ok
+}
+
+func resolvePath(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
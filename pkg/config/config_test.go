@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseValidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		problem string // substring of error, empty if valid
+	}{
+		{name: "empty"},
+		{
+			name:   "settings",
+			config: "output: out\npackage: db\nbackends: [go, ddl]\nkeyStrategy: uuid\nnaming: {policy: short, columns: snake}\n",
+		},
+		{
+			name:   "inputs and overrides",
+			config: "inputs:\n  - schemas: [a.json]\n    package: a\noverrides:\n  - schema: a.json\n    split: true\n",
+		},
+		{name: "unknown key", config: "outputs: out\n", problem: "#"},
+		{name: "unknown key strategy", config: "keyStrategy: serial\n", problem: "#/keyStrategy"},
+		{name: "snake type names", config: "naming: {types: snake}\n", problem: "#/naming/types"},
+		{name: "unknown backend", config: "backends: [java]\n", problem: "#/backends/0"},
+		{name: "input without schemas", config: "inputs:\n  - package: a\n", problem: "#/inputs/0"},
+		{name: "bad type mapping", config: "types: {date: time.Time}\n", problem: "#/types"},
+		{name: "not yaml", config: "output: [\n", problem: "invalid config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.config), "/project")
+			if tt.problem == "" {
+				if err != nil {
+					t.Fatalf("Parse() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("Parse() = %v, want ErrInvalidConfig", err)
+			}
+			if !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("Parse() = %v, want problem at %s", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestExampleConfigIsValid(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "configs", FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(data); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(`
+output: model
+backends: [go]
+naming: {columns: snake, initialisms: [SKU]}
+types: {"string:uuid": github.com/google/uuid.UUID, integer: int64}
+inputs:
+  - schemas: ["*.json"]
+  - schemas: ["billing/*.json"]
+    output: billing
+    package: billing
+    split: true
+    types: {integer: int32}
+overrides:
+  - schema: billing/legacy.json
+    keyStrategy: uuid
+    split: false
+    naming: {columns: kebab}
+`), "/project")
+	if err != nil {
+		t.Fatalf("Parse() = %v", err)
+	}
+
+	split, noSplit := true, false
+	tests := []struct {
+		path string
+		want func(s *Settings)
+	}{
+		{
+			path: "/project/order.json",
+			want: func(s *Settings) {},
+		},
+		{
+			path: "/project/billing/invoice.json",
+			want: func(s *Settings) {
+				s.Output = "/project/billing"
+				s.Package = "billing"
+				s.Split = &split
+				s.Types = map[string]string{"string:uuid": "github.com/google/uuid.UUID", "integer": "int32"}
+			},
+		},
+		{
+			path: "/project/billing/legacy.json",
+			want: func(s *Settings) {
+				s.Output = "/project/billing"
+				s.Package = "billing"
+				s.Types = map[string]string{"string:uuid": "github.com/google/uuid.UUID", "integer": "int32"}
+				s.KeyStrategy = "uuid"
+				s.Split = &noSplit // set back by override
+				s.Naming.Columns = "kebab"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			want := DefaultSettings()
+			want.Output = "/project/model"
+			want.Naming.Columns = "snake"
+			want.Naming.Initialisms = []string{"SKU"}
+			want.Types = map[string]string{"string:uuid": "github.com/google/uuid.UUID", "integer": "int64"}
+			tt.want(&want)
+
+			if got := cfg.Resolve(tt.path); !reflect.DeepEqual(got, want) {
+				t.Errorf("Resolve() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	// merged maps are copies
+	s := cfg.Resolve("/project/billing/invoice.json")
+	s.Types["integer"] = "uint"
+	if cfg.Inputs[1].Types["integer"] != "int32" || cfg.Types["integer"] != "int64" {
+		t.Errorf("Resolve() shares types with config")
+	}
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"dbgen/pkg/modelgen"
+	"github.com/thorn-jmh/errorst"
+	"strings"
+)
+
+// backends
+const (
+	BackendGo       = "go"       // Go models by jennifer
+	BackendTemplate = "template" // files by text/template in Templates
+	BackendDDL      = "ddl"      // SQL DDL of indexes
+)
+
+// Settings are generation settings. Settings declared at top level, by
+// inputs and by overrides are merged, non-empty and set values win.
+type Settings struct {
+	Output      string            `yaml:"output"`      // output directory
+	Package     string            `yaml:"package"`     // package name of generated code
+	Backends    []string          `yaml:"backends"`    // go, template, ddl
+	Templates   string            `yaml:"templates"`   // template directory of template backend
+	Split       *bool             `yaml:"split"`       // one Go file per type, nil if not set
+	Naming      Naming            `yaml:"naming"`      // name styles
+	KeyStrategy string            `yaml:"keyStrategy"` // auto, uuid, ulid
+	Types       map[string]string `yaml:"types"`       // Go types by "type:format" or "type"
+	Mixins      Mixins            `yaml:"mixins"`      // mixins of tables
+}
+
+// Naming declares name styles, one of big-camel, camel, snake, kebab.
+type Naming struct {
+	Policy      string   `yaml:"policy"` // path or short
+	Types       string   `yaml:"types"`
+	Fields      string   `yaml:"fields"`
+	Columns     string   `yaml:"columns"`
+	Tables      string   `yaml:"tables"`
+	Initialisms []string `yaml:"initialisms"`
+}
+
+// Mixins declares mixins applied to tables, and custom mixins.
+type Mixins struct {
+	Root []string                `yaml:"root"`
+	All  []string                `yaml:"all"`
+	Sets map[string][]MixinField `yaml:"sets"`
+}
+
+// MixinField is a field of custom mixin.
+type MixinField struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"` // Go type, e.g. time.Time
+	Tags    map[string]string `yaml:"tags"`
+	Comment string            `yaml:"comment"`
+}
+
+// DefaultSettings returns settings used without config.
+func DefaultSettings() Settings {
+	return Settings{
+		Output:      "./model",
+		Package:     "model",
+		Backends:    []string{BackendGo},
+		KeyStrategy: string(modelgen.KeyStrategyAuto),
+		Naming: Naming{
+			Policy: string(modelgen.NamePolicyPath),
+			Types:  "big-camel",
+			Fields: "big-camel",
+		},
+	}
+}
+
+// Merge overrides s by non-empty values of o, maps are merged by key.
+func (s *Settings) Merge(o Settings) {
+	mergeString(&s.Output, o.Output)
+	mergeString(&s.Package, o.Package)
+	mergeStrings(&s.Backends, o.Backends)
+	mergeString(&s.Templates, o.Templates)
+	mergeString(&s.KeyStrategy, o.KeyStrategy)
+	if o.Split != nil {
+		split := *o.Split
+		s.Split = &split
+	}
+
+	mergeString(&s.Naming.Policy, o.Naming.Policy)
+	mergeString(&s.Naming.Types, o.Naming.Types)
+	mergeString(&s.Naming.Fields, o.Naming.Fields)
+	mergeString(&s.Naming.Columns, o.Naming.Columns)
+	mergeString(&s.Naming.Tables, o.Naming.Tables)
+	mergeStrings(&s.Naming.Initialisms, o.Naming.Initialisms)
+
+	mergeStrings(&s.Mixins.Root, o.Mixins.Root)
+	mergeStrings(&s.Mixins.All, o.Mixins.All)
+
+	// maps are copied, so merged settings share nothing with o
+	if len(o.Types) > 0 {
+		types := make(map[string]string, len(s.Types)+len(o.Types))
+		for k, v := range s.Types {
+			types[k] = v
+		}
+		for k, v := range o.Types {
+			types[k] = v
+		}
+		s.Types = types
+	}
+	if len(o.Mixins.Sets) > 0 {
+		sets := make(map[string][]MixinField, len(s.Mixins.Sets)+len(o.Mixins.Sets))
+		for k, v := range s.Mixins.Sets {
+			sets[k] = v
+		}
+		for k, v := range o.Mixins.Sets {
+			sets[k] = v
+		}
+		s.Mixins.Sets = sets
+	}
+}
+
+// IsSplit reports whether one Go file is generated per type.
+func (s *Settings) IsSplit() bool {
+	return s.Split != nil && *s.Split
+}
+
+// HasBackend reports whether backend is enabled.
+func (s *Settings) HasBackend(backend string) bool {
+	for _, b := range s.Backends {
+		if b == backend {
+			return true
+		}
+	}
+	return false
+}
+
+// Options converts settings to options of model generation.
+func (s *Settings) Options() (*modelgen.Options, error) {
+	opts := modelgen.DefaultOptions()
+
+	// first: name policy and key strategy
+	switch policy := modelgen.NamePolicy(s.Naming.Policy); policy {
+	case "":
+	case modelgen.NamePolicyPath, modelgen.NamePolicyShort:
+		opts.NamePolicy = policy
+	default:
+		return nil, errorst.Wrap(ErrInvalidConfig, "unknown name policy: %s", s.Naming.Policy)
+	}
+
+	switch strategy := modelgen.KeyStrategy(s.KeyStrategy); strategy {
+	case "":
+	case modelgen.KeyStrategyAuto, modelgen.KeyStrategyUUID, modelgen.KeyStrategyULID:
+		opts.KeyStrategy = strategy
+	default:
+		return nil, errorst.Wrap(ErrInvalidConfig, "unknown key strategy: %s", s.KeyStrategy)
+	}
+
+	// second: name styles
+	for _, style := range []struct {
+		name   string
+		dst    *modelgen.NameStyle
+		byName func(name string, initialisms ...string) (modelgen.NameStyle, error)
+	}{
+		{s.Naming.Types, &opts.Naming.Types, modelgen.GoNameStyleByName},
+		{s.Naming.Fields, &opts.Naming.Fields, modelgen.GoNameStyleByName},
+		{s.Naming.Columns, &opts.Naming.Columns, modelgen.NameStyleByName},
+		{s.Naming.Tables, &opts.Naming.Tables, modelgen.NameStyleByName},
+	} {
+		if style.name == "" {
+			continue
+		}
+		ns, err := style.byName(style.name, s.Naming.Initialisms...)
+		if err != nil {
+			return nil, err
+		}
+		*style.dst = ns
+	}
+	opts.Naming.Initialisms = s.Naming.Initialisms
+
+	// third: type mapping
+	if len(s.Types) > 0 {
+		opts.TypeMapping = make(map[string]modelgen.Type, len(s.Types))
+		for key, goType := range s.Types {
+			typ, err := modelgen.ParseGoType(goType)
+			if err != nil {
+				return nil, errorst.Wrap(err, "invalid type mapping of %s", key)
+			}
+			opts.TypeMapping[key] = typ
+		}
+	}
+
+	// forth: mixins
+	opts.Mixins.Root = s.Mixins.Root
+	opts.Mixins.All = s.Mixins.All
+	if len(s.Mixins.Sets) > 0 {
+		opts.Mixins.Sets = make(map[string][]modelgen.Field, len(s.Mixins.Sets))
+		for name, fields := range s.Mixins.Sets {
+			for _, f := range fields {
+				typ, err := modelgen.ParseGoType(f.Type)
+				if err != nil {
+					return nil, errorst.Wrap(err, "invalid field %s of mixin %s", f.Name, name)
+				}
+				opts.Mixins.Sets[name] = append(opts.Mixins.Sets[name], modelgen.Field{
+					Name:    f.Name,
+					Type:    typ,
+					Tags:    f.Tags,
+					Comment: f.Comment,
+				})
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+// check validates values of s, empty values are allowed.
+func (s *Settings) check() error {
+	for _, backend := range s.Backends {
+		switch backend {
+		case BackendGo, BackendTemplate, BackendDDL:
+		default:
+			return errorst.Wrap(ErrInvalidConfig, "unknown backend: %s", backend)
+		}
+	}
+	for key := range s.Types {
+		if !isTypeKey(key) {
+			return errorst.Wrap(ErrInvalidConfig, "invalid type mapping key: %s", key)
+		}
+	}
+	_, err := s.Options()
+	return err
+}
+
+func (s *Settings) resolvePaths(dir string) {
+	s.Output = resolvePath(dir, s.Output)
+	s.Templates = resolvePath(dir, s.Templates)
+}
+
+// isTypeKey checks key of type mapping, e.g. `string:uuid` or `integer`.
+func isTypeKey(key string) bool {
+	primitives := []string{"boolean", "integer", "number", "string"}
+	for _, p := range primitives {
+		if key == p || strings.HasPrefix(key, p+":") && len(key) > len(p)+1 {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeString(dst *string, src string) {
+	if src != "" {
+		*dst = src
+	}
+}
+
+func mergeStrings(dst *[]string, src []string) {
+	if len(src) > 0 {
+		*dst = append([]string(nil), src...)
+	}
+}
@@ -0,0 +1,58 @@
+package lint
+
+import (
+	"bytes"
+	"dbgen/pkg/schemas"
+	"encoding/json"
+	"strconv"
+)
+
+// pointerLines maps JSON Pointers of all values in document data to
+// their lines, the line of a member is the line of its key.
+func pointerLines(data []byte) map[string]int {
+	var (
+		lines = make(map[string]int)
+		dec   = json.NewDecoder(bytes.NewReader(data))
+	)
+	// lineAt skips separators from offset, and returns the line there.
+	lineAt := func(offset int64) int {
+		for offset < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n,:"), data[offset]) >= 0 {
+			offset++
+		}
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	var walk func(pointer string, line int) bool
+	walk = func(pointer string, line int) bool {
+		lines[pointer] = line
+		tok, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				line := lineAt(dec.InputOffset())
+				key, err := dec.Token()
+				if err != nil {
+					return false
+				}
+				name, _ := key.(string)
+				if !walk(pointer+"/"+schemas.EscapePointer(name), line) {
+					return false
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if !walk(pointer+"/"+strconv.Itoa(i), lineAt(dec.InputOffset())) {
+					return false
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err == nil
+	}
+	walk("#", lineAt(0))
+	return lines
+}
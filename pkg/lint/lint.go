@@ -0,0 +1,349 @@
+package lint
+
+import (
+	"bytes"
+	"dbgen/pkg/modelgen"
+	"dbgen/pkg/schemas"
+	"encoding/json"
+	"fmt"
+	"github.com/thorn-jmh/errorst"
+	"sort"
+	"strings"
+)
+
+// >>>>>>>>>>>> lint warns about schema constructs which dbgen can not represent, or represents lossily >>>>>>>>>>>>>>>
+
+// Severity of diagnostics.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // generation fails
+	SeverityWarning Severity = "warning" // generation drops or changes meaning
+)
+
+// Rule is a lint check.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+}
+
+// rules of lint
+var (
+	RuleUnsupportedKeyword    = Rule{"unsupported-keyword", SeverityWarning, "Keyword is ignored by dbgen"}
+	RuleUnknownKeyword        = Rule{"unknown-keyword", SeverityWarning, "Keyword is not of JSON Schema nor an extension, likely a typo"}
+	RuleUnsatisfiableRequired = Rule{"unsatisfiable-required", SeverityWarning, "Required property is not declared in properties"}
+	RuleMixedEnum             = Rule{"mixed-enum", SeverityWarning, "Enum values have different types, but a Go enum has one"}
+	RuleRefSiblings           = Rule{"ref-siblings", SeverityWarning, "Keywords beside $ref are ignored"}
+	RuleMissingType           = Rule{"missing-type", SeverityError, "Schema has neither type nor $ref"}
+	RuleArrayWithoutItems     = Rule{"array-without-items", SeverityError, "Array has no items schema of one type"}
+	RuleNameCollapse          = Rule{"name-collapse", SeverityError, "Property names collapse to the same Go field"}
+)
+
+// Rules are all lint rules.
+var Rules = []Rule{
+	RuleUnsupportedKeyword,
+	RuleUnknownKeyword,
+	RuleUnsatisfiableRequired,
+	RuleMixedEnum,
+	RuleRefSiblings,
+	RuleMissingType,
+	RuleArrayWithoutItems,
+	RuleNameCollapse,
+}
+
+// Diagnostic is a finding of lint.
+type Diagnostic struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Pointer  string   `json:"pointer"` // JSON Pointer as URI fragment
+	Line     int      `json:"line"`    // line of Pointer in schema file
+	Message  string   `json:"message"`
+}
+
+// unsupportedKeywords are applicators dbgen does not generate from.
+var unsupportedKeywords = []string{
+	"not", "contains", "propertyNames", "allOf", "anyOf", "if", "then", "else",
+	"patternProperties", "dependentSchemas", "prefixItems",
+	"unevaluatedItems", "unevaluatedProperties",
+}
+
+// annotations are keywords which do not change what is generated.
+var annotations = map[string]bool{
+	"$comment": true, "$schema": true, "$id": true, "$anchor": true,
+	"$dynamicAnchor": true, "$recursiveAnchor": true,
+}
+
+// refKeywords are keywords referencing another schema.
+var refKeywords = []string{"$ref", "$dynamicRef", "$recursiveRef"}
+
+// linter walks a schema document.
+type linter struct {
+	fields modelgen.NameStyle
+	lines  map[string]int
+	diags  []Diagnostic
+}
+
+// Lint checks the schema document data, property names are formatted to
+// Go fields by fields, or BigCamelStyle if nil. Diagnostics are ordered
+// by their locations.
+func Lint(data []byte, fields modelgen.NameStyle) ([]Diagnostic, error) {
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, errorst.Wrap(err, "failed to unmarshal JSON")
+	}
+	if fields == nil {
+		fields = modelgen.BigCamelStyle
+	}
+
+	l := &linter{fields: fields, lines: pointerLines(data), diags: []Diagnostic{}}
+	l.schema("#", doc)
+	l.unknownKeywords(doc)
+	return l.sorted(), nil
+}
+
+// LintComponent checks component name of the OpenAPI document data, as the
+// JSON Schema it is read as. Pointers are of the component in data.
+func LintComponent(data []byte, name string, fields modelgen.NameStyle) ([]Diagnostic, error) {
+	openAPI, err := schemas.ParseOpenAPI(data)
+	if err != nil {
+		return nil, err
+	}
+	sch, err := openAPI.ComponentSchema(name)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(sch))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, errorst.Wrap(err, "failed to unmarshal JSON")
+	}
+	if fields == nil {
+		fields = modelgen.BigCamelStyle
+	}
+
+	// lines are only known for JSON documents
+	var (
+		base  = schemas.ComponentsRef + schemas.EscapePointer(name)
+		lines = make(map[string]int)
+	)
+	for pointer, line := range pointerLines(data) {
+		if rest, ok := strings.CutPrefix(pointer, base); ok {
+			lines["#"+rest] = line
+		}
+	}
+	l := &linter{fields: fields, lines: lines, diags: []Diagnostic{}}
+	l.schema("#", doc)
+	l.unknownKeywords(doc)
+	for i := range l.diags {
+		l.diags[i].Pointer = base + strings.TrimPrefix(l.diags[i].Pointer, "#")
+	}
+	return l.sorted(), nil
+}
+
+// sorted returns diagnostics ordered by their locations.
+func (l *linter) sorted() []Diagnostic {
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		return l.diags[i].Line < l.diags[j].Line
+	})
+	return l.diags
+}
+
+func (l *linter) report(rule Rule, pointer string, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{
+		Rule:     rule.ID,
+		Severity: rule.Severity,
+		Pointer:  pointer,
+		Line:     l.lines[pointer],
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// schema checks the schema v at pointer and its subschemas.
+func (l *linter) schema(pointer string, v any) {
+	sch, ok := v.(map[string]any)
+	if !ok {
+		// boolean schemas
+		return
+	}
+
+	// first: $ref ignores siblings, which are not checked further
+	for _, ref := range refKeywords {
+		if _, ok := sch[ref]; !ok {
+			continue
+		}
+		var siblings []string
+		for k := range sch {
+			// discriminator value of a subtype is read beside $ref
+			if k != ref && !annotations[k] && k != schemas.ExtensionPrefix+modelgen.HintDiscriminatorValue {
+				siblings = append(siblings, k)
+			}
+		}
+		if len(siblings) > 0 {
+			sort.Strings(siblings)
+			l.report(RuleRefSiblings, pointer, "keywords beside %s are ignored: %s", ref, strings.Join(siblings, ", "))
+		}
+		l.definitions(pointer, sch)
+		return
+	}
+
+	// second: keywords of this schema
+	for _, keyword := range unsupportedKeywords {
+		if _, ok := sch[keyword]; ok {
+			l.report(RuleUnsupportedKeyword, pointer+"/"+keyword, "%s is not supported, it is ignored", keyword)
+		}
+	}
+	if _, ok := sch["additionalProperties"].(map[string]any); ok {
+		l.report(RuleUnsupportedKeyword, pointer+"/additionalProperties", "additionalProperties of schema is not supported, it is ignored")
+	}
+	if _, ok := sch["oneOf"]; ok {
+		if _, ok := sch[schemas.ExtensionPrefix+modelgen.HintInheritance]; !ok {
+			l.report(RuleUnsupportedKeyword, pointer+"/oneOf", "oneOf without %s%s is ignored", schemas.ExtensionPrefix, modelgen.HintInheritance)
+		}
+	}
+
+	types := schemaTypes(sch["type"])
+	if len(types) == 0 {
+		l.report(RuleMissingType, pointer, "schema has no type")
+	}
+	if types["array"] {
+		_, tuple := sch["prefixItems"]
+		if _, ok := sch["items"].([]any); ok || tuple {
+			l.report(RuleArrayWithoutItems, pointer, "tuple arrays are unsupported, use items of one schema")
+		} else if _, ok := sch["items"].(map[string]any); !ok {
+			l.report(RuleArrayWithoutItems, pointer, "array has no items schema")
+		}
+	}
+	l.enum(pointer, sch)
+	l.properties(pointer, sch)
+
+	// third: subschemas
+	if items, ok := sch["items"].(map[string]any); ok {
+		l.schema(pointer+"/items", items)
+	}
+	if oneOf, ok := sch["oneOf"].([]any); ok {
+		for i, sub := range oneOf {
+			l.schema(fmt.Sprintf("%s/oneOf/%d", pointer, i), sub)
+		}
+	}
+	l.definitions(pointer, sch)
+}
+
+// unknownKeywords reports keywords of no JSON Schema draft in doc.
+func (l *linter) unknownKeywords(doc any) {
+	for _, pointer := range schemas.UnknownKeywords(doc) {
+		keyword := pointer[strings.LastIndex(pointer, "/")+1:]
+		keyword = strings.NewReplacer("~1", "/", "~0", "~").Replace(keyword)
+		l.report(RuleUnknownKeyword, pointer, "unknown keyword %s, prefix extensions with %s", keyword, schemas.ExtensionPrefix)
+	}
+}
+
+// properties checks required and names of properties, then each property.
+func (l *linter) properties(pointer string, sch map[string]any) {
+	props, _ := sch["properties"].(map[string]any)
+	if required, ok := sch["required"].([]any); ok {
+		for i, name := range required {
+			if name, ok := name.(string); ok && props[name] == nil {
+				l.report(RuleUnsatisfiableRequired, fmt.Sprintf("%s/required/%d", pointer, i), "required property %s is not in properties", name)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	byField := make(map[string][]string)
+	for _, name := range names {
+		field := l.fields.Format(name)
+		byField[field] = append(byField[field], name)
+	}
+	for _, name := range names {
+		field := l.fields.Format(name)
+		if same := byField[field]; len(same) > 1 && same[0] == name {
+			l.report(RuleNameCollapse, pointer+"/properties", "properties %s collapse to Go field %s", strings.Join(same, ", "), field)
+		}
+	}
+
+	for _, name := range names {
+		l.schema(pointer+"/properties/"+schemas.EscapePointer(name), props[name])
+	}
+}
+
+// enum checks that values of enum have one type.
+func (l *linter) enum(pointer string, sch map[string]any) {
+	values, ok := sch["enum"].([]any)
+	if !ok {
+		return
+	}
+	seen := make(map[string]bool)
+	var kinds []string
+	for _, value := range values {
+		kind := valueKind(value)
+		if kind != "null" && !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) > 1 {
+		l.report(RuleMixedEnum, pointer+"/enum", "enum mixes values of %s", strings.Join(kinds, ", "))
+	}
+}
+
+// definitions checks schemas of $defs and legacy definitions.
+func (l *linter) definitions(pointer string, sch map[string]any) {
+	for _, keyword := range []string{"$defs", "definitions"} {
+		defs, ok := sch[keyword].(map[string]any)
+		if !ok {
+			continue
+		}
+		names := make([]string, 0, len(defs))
+		for name := range defs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			l.schema(pointer+"/"+keyword+"/"+schemas.EscapePointer(name), defs[name])
+		}
+	}
+}
+
+// schemaTypes returns types of the type keyword v.
+func schemaTypes(v any) map[string]bool {
+	types := make(map[string]bool)
+	switch v := v.(type) {
+	case string:
+		types[v] = true
+	case []any:
+		for _, t := range v {
+			if t, ok := t.(string); ok {
+				types[t] = true
+			}
+		}
+	}
+	return types
+}
+
+// valueKind is the JSON type of value decoded with UseNumber,
+// integers are numbers.
+func valueKind(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
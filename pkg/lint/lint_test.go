@@ -0,0 +1,191 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+// finding is a diagnostic without message.
+type finding struct {
+	rule    string
+	pointer string
+	line    int
+}
+
+func findings(diags []Diagnostic) []finding {
+	got := make([]finding, 0, len(diags))
+	for _, d := range diags {
+		got = append(got, finding{d.Rule, d.Pointer, d.Line})
+	}
+	return got
+}
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   []finding
+	}{
+		{
+			name:   "clean",
+			schema: `{"type": "object", "properties": {"name": {"type": "string"}, "tags": {"type": "array", "items": {"type": "string"}}}}`,
+			want:   []finding{},
+		},
+		{
+			name: "unsupported keyword",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "not": {"const": ""}}
+  },
+  "additionalProperties": {"type": "string"}
+}`,
+			want: []finding{
+				{RuleUnsupportedKeyword.ID, "#/properties/name/not", 4},
+				{RuleUnsupportedKeyword.ID, "#/additionalProperties", 6},
+			},
+		},
+		{
+			name: "unsatisfiable required",
+			schema: `{
+  "type": "object",
+  "properties": {"name": {"type": "string"}},
+  "required": ["name", "age"]
+}`,
+			want: []finding{{RuleUnsatisfiableRequired.ID, "#/required/1", 4}},
+		},
+		{
+			name: "mixed enum",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "level": {"type": ["integer", "string", "null"], "enum": [1, "high", null]},
+    "state": {"type": ["string", "null"], "enum": ["open", null]}
+  }
+}`,
+			want: []finding{{RuleMixedEnum.ID, "#/properties/level/enum", 4}},
+		},
+		{
+			name: "ref siblings",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "owner": {"$ref": "#/$defs/owner", "description": "ignored", "$comment": "annotation"},
+    "cat": {"$ref": "#/$defs/owner", "x-discriminator-value": "cat"}
+  },
+  "$defs": {"owner": {"type": "object"}}
+}`,
+			want: []finding{{RuleRefSiblings.ID, "#/properties/owner", 4}},
+		},
+		{
+			name: "missing type",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "any": {"description": "anything"}
+  }
+}`,
+			want: []finding{{RuleMissingType.ID, "#/properties/any", 4}},
+		},
+		{
+			name: "array without items",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "list": {"type": "array"}
+  }
+}`,
+			want: []finding{{RuleArrayWithoutItems.ID, "#/properties/list", 4}},
+		},
+		{
+			name: "tuple array",
+			schema: `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "point": {"type": "array", "items": [{"type": "number"}, {"type": "number"}]}
+  }
+}`,
+			want: []finding{{RuleArrayWithoutItems.ID, "#/properties/point", 5}},
+		},
+		{
+			name: "name collapse",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "user_id": {"type": "string"},
+    "userId": {"type": "string"}
+  }
+}`,
+			want: []finding{{RuleNameCollapse.ID, "#/properties", 3}},
+		},
+		{
+			name: "unknown keyword",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "maxLenght": 8, "x-index": true}
+  },
+  "$defs": {"a": {"type": "string", "desciption": "typo"}},
+  "additionalProperty": false
+}`,
+			want: []finding{
+				{RuleUnknownKeyword.ID, "#/properties/name/maxLenght", 4},
+				{RuleUnknownKeyword.ID, "#/$defs/a/desciption", 6},
+				{RuleUnknownKeyword.ID, "#/additionalProperty", 7},
+			},
+		},
+		{
+			name: "definitions",
+			schema: `{
+  "type": "object",
+  "$defs": {
+    "a/b": {"type": "array"}
+  }
+}`,
+			want: []finding{{RuleArrayWithoutItems.ID, "#/$defs/a~1b", 4}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags, err := Lint([]byte(tt.schema), nil)
+			if err != nil {
+				t.Fatalf("Lint() = %v", err)
+			}
+			if got := findings(diags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintComponent(t *testing.T) {
+	doc := []byte(`openapi: 3.0.3
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        tags:
+          type: array
+        owner:
+          $ref: '#/components/schemas/Owner'
+        name:
+          type: string
+          nullable: true
+          maxLenght: 8
+    Owner:
+      type: object
+`)
+	diags, err := LintComponent(doc, "Pet", nil)
+	if err != nil {
+		t.Fatalf("LintComponent() = %v", err)
+	}
+	want := []finding{
+		{RuleArrayWithoutItems.ID, "#/components/schemas/Pet/properties/tags", 0},
+		{RuleUnknownKeyword.ID, "#/components/schemas/Pet/properties/name/maxLenght", 0},
+	}
+	if got := findings(diags); !reflect.DeepEqual(got, want) {
+		t.Errorf("LintComponent() = %+v, want %+v", got, want)
+	}
+}
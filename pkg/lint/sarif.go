@@ -0,0 +1,118 @@
+package lint
+
+import (
+	"encoding/json"
+	"github.com/thorn-jmh/errorst"
+	"path/filepath"
+)
+
+// >>>>>>>>>>>> SARIF 2.1.0 output, for code scanning tools >>>>>>>>>>>>>>>
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// FileDiagnostics are diagnostics of a schema file.
+type FileDiagnostics struct {
+	File        string       `json:"file"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level Severity `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     Severity        `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"` // JSON Pointer
+}
+
+// SARIF renders diagnostics of files as a SARIF log of one run.
+func SARIF(files []FileDiagnostics) ([]byte, error) {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "dbgen"}},
+		Results: []sarifResult{},
+	}
+	indexes := make(map[string]int, len(Rules))
+	for i, rule := range Rules {
+		r := sarifRule{ID: rule.ID, ShortDescription: sarifMessage{rule.Description}}
+		r.DefaultConfiguration.Level = rule.Severity
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, r)
+		indexes[rule.ID] = i
+	}
+
+	for _, file := range files {
+		for _, diag := range file.Diagnostics {
+			var loc sarifLocation
+			loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(file.File)
+			if diag.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: diag.Line}
+			}
+			loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: diag.Pointer}}
+
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    diag.Rule,
+				RuleIndex: indexes[diag.Rule],
+				Level:     diag.Severity,
+				Message:   sarifMessage{diag.Message},
+				Locations: []sarifLocation{loc},
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to marshal SARIF")
+	}
+	return data, nil
+}
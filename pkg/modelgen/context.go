@@ -1,11 +1,43 @@
 package modelgen
 
+import "dbgen/pkg/schemas"
+
+// Context is passed down through generation, nothing is shared
+// between generations so they can run concurrently.
 type Context struct {
+	*Options
+	Schema     *schemas.Schema    // main schema, where $ref are resolved
+	Names      *NameRegistry      // type names of this generation
+	Polymorphs map[string]*Object // polymorphic children by $ref, shared by parents
+	Ancestors  []Ancestor         // root and schemas entered by $ref, outermost first
 	State
 }
 
+// Ancestor is a schema being generated, a $ref to it is recursion.
+type Ancestor struct {
+	Pointer string             // JSON Pointer of the schema
+	Path    string             // path of the object generated from it
+	Schema  *schemas.SubSchema // the schema
+}
+
+// withAncestor returns a copy of ctx entering ancestor, the
+// ancestors of ctx are not changed.
+func (c Context) withAncestor(ancestor Ancestor) Context {
+	c.Ancestors = append(c.Ancestors[:len(c.Ancestors):len(c.Ancestors)], ancestor)
+	return c
+}
+
 type State struct {
 	Require    bool   // is current object required
 	Path       string // current object's path
 	ParentPath string // current object's parent
+	DefName    string // name in $defs if current object is referenced by $ref
+	Pointer    string // JSON Pointer of current schema, as URI fragment
+	Inline     bool   // $ref to a declared object is generated again
+}
+
+// WithState returns a copy of ctx with the given state.
+func (c Context) WithState(state State) Context {
+	c.State = state
+	return c
 }
@@ -0,0 +1,80 @@
+package modelgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// GenDDL generates SQL DDL of the indexes of all tables in obj.
+func GenDDL(obj *Object) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("-- " + GeneratedHeader + "\n")
+	genTableDDL(&buf, obj)
+	return buf.Bytes()
+}
+
+func genTableDDL(buf *bytes.Buffer, obj *Object) {
+	if len(obj.Indexes) > 0 {
+		table := tableName(obj)
+		fmt.Fprintf(buf, "\n-- indexes of %s\n", table)
+		for _, index := range obj.Indexes {
+			buf.WriteString(indexDDL(obj, table, index))
+		}
+	}
+
+	forEachTable(obj, func(sub *Object) {
+		genTableDDL(buf, sub)
+	})
+}
+
+func indexDDL(obj *Object, table string, index Index) string {
+	var columns []string
+	for _, column := range index.Columns {
+		name := column.Field
+		if i := fieldIndex(obj, column.Field); i >= 0 {
+			name = columnName(&obj.Fields[i])
+		}
+		if column.Order != "" {
+			name += " " + strings.ToUpper(column.Order)
+		}
+		columns = append(columns, name)
+	}
+
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if index.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	fmt.Fprintf(&b, "INDEX %s ON %s", index.Name, table)
+	if index.Type != "" {
+		fmt.Fprintf(&b, " USING %s", index.Type)
+	}
+	fmt.Fprintf(&b, " (%s)", strings.Join(columns, ", "))
+	if index.Where != "" {
+		fmt.Fprintf(&b, " WHERE %s", index.Where)
+	}
+	b.WriteString(";\n")
+	return b.String()
+}
+
+// tableName is the table name of obj, from TableName or gorm naming.
+func tableName(obj *Object) string {
+	if obj.TableName != "" {
+		return obj.TableName
+	}
+	return pluralize(SnakeStyle(obj.Name))
+}
+
+// pluralize is a simplified version of the pluralization of gorm naming.
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsAny(name[len(name)-2:len(name)-1], "aeiou"):
+		return name[:len(name)-1] + "ies"
+	default:
+		return name + "s"
+	}
+}
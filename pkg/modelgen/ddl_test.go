@@ -0,0 +1,79 @@
+package modelgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenDDL(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   []string // lines of DDL after the header
+	}{
+		{
+			name:   "no indexes",
+			schema: `{"$id": "Order", "type": "object", "properties": {"sku": {"type": "string"}}}`,
+		},
+		{
+			name: "indexes",
+			schema: `{"$id": "Order", "type": "object", "properties": {
+				"sku": {"type": "string", "x-unique": true},
+				"status": {"type": "string"},
+				"createdAt": {"type": "string", "format": "date-time"}
+			}, "x-indexes": [{"columns": ["status", "createdAt desc"], "where": "status <> 'closed'", "type": "btree"}]}`,
+			want: []string{
+				"",
+				"-- indexes of orders",
+				"CREATE INDEX idx_order_status_created_at ON orders USING btree (status, created_at DESC) WHERE status <> 'closed';",
+				"CREATE UNIQUE INDEX idx_order_sku ON orders (sku);",
+			},
+		},
+		{
+			name: "child tables",
+			schema: `{"$id": "Box", "type": "object", "properties": {
+				"label": {"type": "string", "x-index": true},
+				"entries": {"type": "array", "items": {"type": "object", "properties": {"key": {"type": "string", "x-index": true}}}}
+			}}`,
+			want: []string{
+				"",
+				"-- indexes of boxes",
+				"CREATE INDEX idx_box_label ON boxes (label);",
+				"",
+				"-- indexes of box_entries_items",
+				"CREATE INDEX idx_box_entries_item_key ON box_entries_items (key);",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := GenAndProcess(mustSchema(t, tt.schema), nil)
+			if err != nil {
+				t.Fatalf("GenAndProcess() = %v", err)
+			}
+			want := strings.Join(append([]string{"-- Code generated by dbgen. DO NOT EDIT."}, tt.want...), "\n") + "\n"
+			if got := string(GenDDL(obj)); got != want {
+				t.Errorf("GenDDL() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"order", "orders"},
+		{"box", "boxes"},
+		{"address", "addresses"},
+		{"batch", "batches"},
+		{"category", "categories"},
+		{"key", "keys"},
+	}
+	for _, tt := range tests {
+		if got := pluralize(tt.name); got != tt.want {
+			t.Errorf("pluralize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package modelgen
+
+import (
+	"dbgen/pkg/schemas"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// >>>>>>>>>>>> explain tells which schema location each type, field, table and column comes from >>>>>>>>>>>>>>>
+
+// Origin records where a type or field is generated from, and the rules
+// applied to it by generation and passes.
+type Origin struct {
+	Pointer string   // JSON Pointer of the schema as URI fragment, empty if generated
+	Rules   []string // rules applied, in order
+}
+
+// AddRule appends a rule to o, nothing is done if o is nil.
+func (o *Origin) AddRule(format string, args ...any) {
+	if o != nil {
+		o.Rules = append(o.Rules, fmt.Sprintf(format, args...))
+	}
+}
+
+// kinds of explanations
+const (
+	ExplainTable       = "table"        // struct type stored in its own table
+	ExplainStruct      = "struct"       // struct type stored in the table of its owner
+	ExplainJSONStruct  = "json-struct"  // struct type stored in json
+	ExplainEnum        = "enum"         // enum type
+	ExplainAlias       = "alias"        // alias type
+	ExplainColumn      = "column"       // field stored in a column
+	ExplainJSON        = "json"         // field stored in a json column
+	ExplainJSONKey     = "json-key"     // field of a struct stored in json
+	ExplainInlined     = "inlined"      // field of struct type embedded in the table of its owner
+	ExplainSubRelation = "sub-relation" // field of child table rows
+)
+
+// Explanation tells what is generated from a JSON Pointer of the schema.
+type Explanation struct {
+	Pointer string   `json:"pointer"` // JSON Pointer of the schema, of owner if generated
+	Kind    string   `json:"kind"`
+	Type    string   `json:"type"`             // Go type, or the owner type of field
+	Field   string   `json:"field,omitempty"`  // Go field
+	Table   string   `json:"table,omitempty"`  // table storing it
+	Column  string   `json:"column,omitempty"` // column storing it
+	Rules   []string `json:"rules,omitempty"`
+}
+
+// Explain explains types and fields of the processed model obj,
+// ordered by JSON Pointer.
+func Explain(obj *Object) []Explanation {
+	var e []Explanation
+	explainObject(&e, obj, obj, ExplainTable, "")
+	sort.SliceStable(e, func(i, j int) bool {
+		return e[i].Pointer < e[j].Pointer
+	})
+	return e
+}
+
+// explainObject explains obj of kind and its tree, table is the
+// table storing obj, nil if obj is stored in json.
+func explainObject(e *[]Explanation, obj *Object, table *Object, kind string, parent string) {
+	origin := obj.Origin
+	if origin == nil {
+		origin = &Origin{Pointer: parent}
+	}
+	ex := Explanation{
+		Pointer: origin.Pointer,
+		Kind:    kind,
+		Type:    obj.Name,
+		Rules:   origin.Rules,
+	}
+	if table != nil {
+		ex.Table = tableName(table)
+	}
+	*e = append(*e, ex)
+
+	// first: fields, generated ones are explained at obj
+	for i := range obj.Fields {
+		field := &obj.Fields[i]
+		ex := Explanation{
+			Pointer: origin.Pointer,
+			Type:    obj.Name,
+			Field:   field.Name,
+		}
+		if field.Origin != nil {
+			if field.Origin.Pointer != "" {
+				ex.Pointer = field.Origin.Pointer
+			}
+			ex.Rules = field.Origin.Rules
+		}
+		if field.Name == "" {
+			ex.Field = field.Type.Name
+		}
+
+		gormTag := field.Tags["gorm"]
+		switch {
+		case table == nil:
+			ex.Kind = ExplainJSONKey
+		case field.Name == "" || strings.Contains(gormTag, "embedded"):
+			ex.Kind = ExplainInlined
+			ex.Table = tableName(table)
+		case !isColumnField(field):
+			ex.Kind = ExplainSubRelation
+		default:
+			ex.Kind = ExplainColumn
+			if strings.Contains(gormTag, "serializer:json") {
+				ex.Kind = ExplainJSON
+			}
+			ex.Table = tableName(table)
+			ex.Column = columnName(field)
+		}
+		*e = append(*e, ex)
+	}
+
+	// second: definitions, stored as their owner stores them
+	for _, def := range obj.Definitions {
+		switch def := def.(type) {
+		case *Object:
+			switch {
+			case def.Discriminator != nil:
+				explainObject(e, def, def, ExplainTable, origin.Pointer)
+			case table == nil || isJSONStored(obj, def):
+				explainObject(e, def, nil, ExplainJSONStruct, origin.Pointer)
+			default:
+				explainObject(e, def, table, ExplainStruct, origin.Pointer)
+			}
+		case *Enum:
+			*e = append(*e, explainAlias(&def.Alias, ExplainEnum, origin.Pointer))
+		case *Alias:
+			*e = append(*e, explainAlias(def, ExplainAlias, origin.Pointer))
+		}
+	}
+
+	// third: sub relations are tables
+	for _, sub := range obj.SubRelations {
+		explainObject(e, sub, sub, ExplainTable, origin.Pointer)
+	}
+}
+
+func explainAlias(alias *Alias, kind string, parent string) Explanation {
+	ex := Explanation{
+		Pointer: parent,
+		Kind:    kind,
+		Type:    alias.Name,
+	}
+	if alias.Origin != nil {
+		ex.Pointer = alias.Origin.Pointer
+		ex.Rules = alias.Origin.Rules
+	}
+	return ex
+}
+
+// isJSONStored reports whether def is only referred by json columns of obj.
+func isJSONStored(obj *Object, def *Object) bool {
+	referred := false
+	for _, field := range obj.Fields {
+		if field.Type.Name != def.Name || field.Type.Domain != "" {
+			continue
+		}
+		if !strings.Contains(field.Tags["gorm"], "serializer:json") {
+			return false
+		}
+		referred = true
+	}
+	return referred
+}
+
+// explainRef adds the $ref at pointer from to obj generated from $defs.
+// Fields of unnamed obj are located at from, where they are declared.
+func explainRef(obj *Object, from string) {
+	if isNamedObject(obj) {
+		obj.Origin.AddRule("referenced by $ref at %s", from)
+		return
+	}
+	for _, field := range obj.Fields {
+		if field.Origin != nil {
+			field.Origin.AddRule("$ref to %s", field.Origin.Pointer)
+			field.Origin.Pointer = from
+		}
+	}
+}
+
+// arrayStorageRule tells where the array storage of sch comes from.
+func arrayStorageRule(sch *schemas.SubSchema) string {
+	if getStringHint(sch, HintArrayStorage) != "" {
+		return "by " + schemas.ExtensionPrefix + HintArrayStorage
+	}
+	return "by default"
+}
+
+// tableStorageRule tells why sch is stored in table, nested arrays
+// without hint are stored as their outer array.
+func tableStorageRule(sch *schemas.SubSchema) string {
+	if getStringHint(sch, HintArrayStorage) != "" {
+		return "by " + schemas.ExtensionPrefix + HintArrayStorage
+	}
+	return "as items of outer array"
+}
+
+// typeString is the qualified name of typ.
+func typeString(typ Type) string {
+	if typ.Domain != "" {
+		return typ.Domain + "." + typ.Name
+	}
+	return typ.Name
+}
+
+// originOf returns the origin of decl.
+func originOf(decl Decl) *Origin {
+	switch decl := decl.(type) {
+	case *Object:
+		return decl.Origin
+	case *Alias:
+		return decl.Origin
+	case *Enum:
+		return decl.Origin
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package modelgen
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	const schema = `{"$id": "Order", "type": "object", "properties": {
+		"no": {"type": "string"},
+		"status": {"type": "string", "enum": ["open", "closed"]},
+		"address": {"type": "object", "properties": {"city": {"type": "string"}}},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"notes": {"type": "array", "items": {"type": "array", "items": {"type": "object", "properties": {"text": {"type": "string"}}}}},
+		"lines": {"type": "array", "items": {"$ref": "#/$defs/line"}}
+	}, "$defs": {"line": {"type": "object", "properties": {"sku": {"type": "string"}}}}}`
+	obj, err := GenAndProcess(mustSchema(t, schema), nil)
+	if err != nil {
+		t.Fatalf("GenAndProcess() = %v", err)
+	}
+
+	// explanations as "pointer kind Type.Field table.column"
+	var got []string
+	for _, ex := range Explain(obj) {
+		got = append(got, fmt.Sprintf("%s %s %s.%s %s.%s", ex.Pointer, ex.Kind, ex.Type, ex.Field, ex.Table, ex.Column))
+	}
+	want := []string{
+		"# table Order. orders.",
+		"# column Order.ID orders.id",
+		"#/$defs/line table OrderLinesItem. order_lines_items.",
+		"#/$defs/line column OrderLinesItem.OrderID order_lines_items.order_id",
+		"#/$defs/line column OrderLinesItem.ID order_lines_items.id",
+		"#/$defs/line/properties/sku column OrderLinesItem.Sku order_lines_items.sku",
+		"#/properties/address inlined Order.Address orders.",
+		"#/properties/address struct OrderAddress. orders.",
+		"#/properties/address/properties/city column OrderAddress.City orders.city",
+		"#/properties/lines sub-relation Order.LinesItems .",
+		"#/properties/no column Order.No orders.no",
+		"#/properties/notes json Order.Notes orders.notes",
+		"#/properties/notes/items/items json-struct OrderNotesItemItem. .",
+		"#/properties/notes/items/items/properties/text json-key OrderNotesItemItem.Text .",
+		"#/properties/status column Order.Status orders.status",
+		"#/properties/status enum OrderStatus. .",
+		"#/properties/tags json Order.Tags orders.tags",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Explain() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -1,6 +1,11 @@
 package modelgen
 
-import "strings"
+import (
+	"github.com/thorn-jmh/errorst"
+	"go/token"
+	"strings"
+	"unicode"
+)
 
 type NameStyle interface {
 	Format(name string) string
@@ -12,36 +17,232 @@ func (f NameStyleFunc) Format(name string) string {
 	return f(name)
 }
 
-var BigCamelStyle NameStyleFunc = func(name string) string {
-	words := strings.Split(name, "_")
-	for i, word := range words {
-		if len(word) <= 1 {
-			words[i] = strings.ToUpper(word)
+// CommonInitialisms are words which should be upper cased in Go identifiers.
+// https://github.com/golang/lint/blob/master/lint.go
+var CommonInitialisms = []string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP",
+	"HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA",
+	"SMTP", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "UUID",
+	"URI", "URL", "UTF8", "VM", "XML", "XMPP", "XSRF", "XSS",
+}
+
+// BigCamelStyle formats name as exported Go identifier, like `UserID`.
+var BigCamelStyle = NewBigCamelStyle()
+
+// CamelStyle formats name as unexported Go identifier, like `userID`.
+var CamelStyle = NewCamelStyle()
+
+// SnakeStyle formats name like `user_id`.
+var SnakeStyle = NewSnakeStyle()
+
+// KebabStyle formats name like `user-id`.
+var KebabStyle = NewKebabStyle()
+
+// NewBigCamelStyle returns BigCamelStyle with extra initialisms.
+func NewBigCamelStyle(initialisms ...string) NameStyleFunc {
+	set := initialismSet(initialisms)
+	return func(name string) string {
+		return goIdentifier(camelWords(splitWords(name, set), set, true), "X")
+	}
+}
+
+// NewCamelStyle returns CamelStyle with extra initialisms.
+func NewCamelStyle(initialisms ...string) NameStyleFunc {
+	set := initialismSet(initialisms)
+	return func(name string) string {
+		return goIdentifier(camelWords(splitWords(name, set), set, false), "x")
+	}
+}
+
+// NewSnakeStyle returns SnakeStyle with extra initialisms, which are
+// words of their own, e.g. `SKUID` is `sku_id` if SKU is an initialism.
+func NewSnakeStyle(initialisms ...string) NameStyleFunc {
+	set := initialismSet(initialisms)
+	return func(name string) string {
+		return strings.ToLower(strings.Join(splitWords(name, set), "_"))
+	}
+}
+
+// NewKebabStyle returns KebabStyle with extra initialisms.
+func NewKebabStyle(initialisms ...string) NameStyleFunc {
+	set := initialismSet(initialisms)
+	return func(name string) string {
+		return strings.ToLower(strings.Join(splitWords(name, set), "-"))
+	}
+}
+
+// NameStyleByName returns name style by its name, which is
+// one of `big-camel`, `camel`, `snake`, `kebab`.
+func NameStyleByName(name string, initialisms ...string) (NameStyle, error) {
+	switch name {
+	case "big-camel":
+		return NewBigCamelStyle(initialisms...), nil
+	case "camel":
+		return NewCamelStyle(initialisms...), nil
+	case "snake":
+		return NewSnakeStyle(initialisms...), nil
+	case "kebab":
+		return NewKebabStyle(initialisms...), nil
+	default:
+		return nil, errorst.Wrap(ErrWrongSyntax, "unknown name style: %s", name)
+	}
+}
+
+// GoNameStyleByName returns name style of Go identifiers by its name, which
+// is one of `big-camel`, `camel`, as others are not valid Go identifiers.
+func GoNameStyleByName(name string, initialisms ...string) (NameStyle, error) {
+	switch name {
+	case "big-camel", "camel":
+		return NameStyleByName(name, initialisms...)
+	default:
+		return nil, errorst.Wrap(ErrWrongSyntax, "name style %s of Go names must be big-camel or camel", name)
+	}
+}
+
+// splitWords splits name by separators, case changes and digits.
+// e.g. `user-id`, `userId`, `user_ID` and `user id` are all split to [user id],
+// `HTTPServer2` is split to [HTTP Server2]. Upper case words made of
+// initialisms are split into them, and keep their plural, e.g. `SKUIDs`
+// is split to [SKU IDs].
+func splitWords(name string, initialisms map[string]bool) []string {
+	var (
+		words []string
+		runes = []rune(name)
+		start = -1 // start of current word, -1 if there's no word
+	)
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			words = append(words, string(runes[start:end]))
+		}
+		start = -1
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(i)
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+
+		prev := runes[i-1]
+		switch {
+		case unicode.IsLower(prev) && unicode.IsUpper(r):
+			// userId
+			flush(i)
+		case unicode.IsUpper(prev) && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// HTTPServer, but not IDs
+			if !isPluralInitialisms(runes[start:], initialisms) {
+				flush(i)
+			}
+		case unicode.IsDigit(prev) && unicode.IsLetter(r):
+			// 2fa, digits stick to the preceding word like utf8
+			flush(i)
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(runes))
+
+	var split []string
+	for _, word := range words {
+		if parts := splitInitialisms(word, initialisms); len(parts) > 1 {
+			split = append(split, parts...)
 		} else {
-			words[i] = strings.ToUpper(string(word[0])) + word[1:]
+			split = append(split, word)
 		}
 	}
-	return strings.Join(words, "")
+	return split
 }
 
-var SnakeStyle NameStyleFunc = func(name string) string {
-	// split by upper case
-	var words []string
-	var word string
-	for i, c := range name {
-		if i > 0 && isUpper(c) && !isUpper(rune(name[i-1])) {
-			words = append(words, word)
-			word = ""
-			if i < len(name)-1 && !isUpper(rune(name[i+1])) {
-				c = rune(strings.ToLower(string(c))[0])
-			}
+// isPluralInitialisms reports whether runes begin with a word of initialisms
+// followed by a plural `s`, which ends the word, like `IDs` or `URLsOf`.
+func isPluralInitialisms(runes []rune, initialisms map[string]bool) bool {
+	for i := 1; i+1 < len(runes); i++ {
+		if !unicode.IsUpper(runes[i-1]) {
+			return false
+		}
+		if runes[i] == 's' && (i+1 == len(runes) || !unicode.IsLower(runes[i+1])) {
+			return splitInitialisms(string(runes[:i]), initialisms) != nil
 		}
-		word += string(c)
 	}
+	return len(runes) > 1 && runes[len(runes)-1] == 's' && splitInitialisms(string(runes[:len(runes)-1]), initialisms) != nil
+}
 
-	return strings.Join(words, "_")
+// splitInitialisms splits an upper case word, or its plural, into initialisms,
+// it returns nil if word is not made of initialisms.
+func splitInitialisms(word string, initialisms map[string]bool) []string {
+	if word == "" || strings.ToUpper(word) != word && !strings.HasSuffix(word, "s") {
+		return nil
+	}
+	upper, plural := word, ""
+	if strings.HasSuffix(word, "s") {
+		upper, plural = word[:len(word)-1], "s"
+	}
+	if upper == "" || strings.ToUpper(upper) != upper {
+		return nil
+	}
+	if initialisms[upper] {
+		return []string{upper + plural}
+	}
+	// longer initialisms first, e.g. UUID before UID
+	for i := len(upper) - 1; i > 0; i-- {
+		if !initialisms[upper[:i]] {
+			continue
+		}
+		if rest := splitInitialisms(upper[i:]+plural, initialisms); rest != nil {
+			return append([]string{upper[:i]}, rest...)
+		}
+	}
+	return nil
+}
+
+func camelWords(words []string, initialisms map[string]bool, exported bool) string {
+	var b strings.Builder
+	for i, word := range words {
+		upper := strings.ToUpper(word)
+		switch {
+		case i == 0 && !exported:
+			b.WriteString(strings.ToLower(word))
+		case initialisms[upper]:
+			b.WriteString(upper)
+		case strings.HasSuffix(word, "s") && initialisms[upper[:len(upper)-1]]:
+			// plural of initialism, like IDs
+			b.WriteString(upper[:len(upper)-1] + "s")
+		default:
+			runes := []rune(strings.ToLower(word))
+			runes[0] = unicode.ToUpper(runes[0])
+			b.WriteString(string(runes))
+		}
+	}
+	return b.String()
 }
 
-func isUpper(c rune) bool {
-	return c >= 'A' && c <= 'Z'
+// goIdentifier makes sure name is a valid Go identifier, a name
+// without letters or digits is the prefix alone.
+func goIdentifier(name string, prefix string) string {
+	if name == "" {
+		return prefix
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = prefix + name
+	}
+	if token.IsKeyword(name) {
+		name += "_"
+	}
+	return name
+}
+
+func initialismSet(extra []string) map[string]bool {
+	set := make(map[string]bool, len(CommonInitialisms)+len(extra))
+	for _, word := range CommonInitialisms {
+		set[word] = true
+	}
+	for _, word := range extra {
+		set[strings.ToUpper(word)] = true
+	}
+	return set
 }
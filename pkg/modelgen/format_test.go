@@ -0,0 +1,93 @@
+package modelgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"user-id", []string{"user", "id"}},
+		{"userId", []string{"user", "Id"}},
+		{"user_ID", []string{"user", "ID"}},
+		{"user id", []string{"user", "id"}},
+		{"HTTPServer2", []string{"HTTP", "Server2"}},
+		{"2fa", []string{"2", "fa"}},
+		{"utf8Name", []string{"utf8", "Name"}},
+		{"--", nil},
+		{"SKUCode", []string{"SKU", "Code"}},
+		{"SKUID", []string{"SKU", "ID"}},
+		{"userIDs", []string{"user", "IDs"}},
+		{"URLsOfUser", []string{"URLs", "Of", "User"}},
+		{"ABCDef", []string{"ABC", "Def"}},
+	}
+	initialisms := initialismSet([]string{"sku"})
+	for _, tt := range tests {
+		if got := splitWords(tt.name, initialisms); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNameStyles(t *testing.T) {
+	tests := []struct {
+		style       string
+		initialisms []string
+		name        string
+		want        string
+	}{
+		{"big-camel", nil, "user_id", "UserID"},
+		{"big-camel", nil, "http-server", "HTTPServer"},
+		{"big-camel", nil, "2fa", "X2Fa"},
+		{"big-camel", []string{"sku"}, "order sku", "OrderSKU"},
+		{"camel", nil, "user_id", "userID"},
+		{"camel", nil, "ID", "id"},
+		{"camel", nil, "type", "type_"},
+		{"camel", nil, "2fa", "x2Fa"},
+		{"camel", nil, "--", "x"},
+		{"big-camel", nil, "--", "X"},
+		{"big-camel", nil, "user_ids", "UserIDs"},
+		{"snake", nil, "UserID", "user_id"},
+		{"snake", []string{"sku"}, "orderSKU", "order_sku"},
+		{"snake", []string{"sku"}, "SKUCode", "sku_code"},
+		{"snake", []string{"sku"}, "SKUID", "sku_id"},
+		{"snake", nil, "SKUCode", "sku_code"},
+		{"snake", nil, "userIDs", "user_ids"},
+		{"kebab", []string{"sku"}, "SKUID", "sku-id"},
+		{"kebab", nil, "HTTPServer", "http-server"},
+	}
+	for _, tt := range tests {
+		style, err := NameStyleByName(tt.style, tt.initialisms...)
+		if err != nil {
+			t.Fatalf("NameStyleByName(%q) = %v", tt.style, err)
+		}
+		if got := style.Format(tt.name); got != tt.want {
+			t.Errorf("%s Format(%q) = %q, want %q", tt.style, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStyleByName(t *testing.T) {
+	tests := []struct {
+		style      string
+		ok, goName bool // valid style, and valid for Go names
+	}{
+		{"big-camel", true, true},
+		{"camel", true, true},
+		{"snake", true, false},
+		{"kebab", true, false},
+		{"pascal", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		if _, err := NameStyleByName(tt.style); (err == nil) != tt.ok {
+			t.Errorf("NameStyleByName(%q) = %v, want ok %v", tt.style, err, tt.ok)
+		}
+		if _, err := GoNameStyleByName(tt.style); (err == nil) != tt.goName {
+			t.Errorf("GoNameStyleByName(%q) = %v, want ok %v", tt.style, err, tt.goName)
+		}
+	}
+}
@@ -11,11 +11,30 @@ type Decl interface {
 }
 
 func (d *Object) Gen(f *jen.File) error {
+	d.genType(f)
+
+	// declare definitions
+	for _, def := range d.Definitions {
+		if err := def.Gen(f); err != nil {
+			return errorst.Wrap(err, "failed to generate definition<%s>", def)
+		}
+	}
+
+	// declare sub relations
+	for _, sub := range d.SubRelations {
+		if err := sub.Gen(f); err != nil {
+			return errorst.Wrap(err, "failed to generate sub relation<%s>", sub.Name)
+		}
+	}
+
+	return nil
+}
+
+// genType declares the struct type and its methods, without definitions
+// and sub relations.
+func (d *Object) genType(f *jen.File) {
 	// first declare struct fields
 	var fieldsDecl = func(g *jen.Group) {
-		// add gorm model
-		// declGormModel(g)
-
 		// decl fields
 		for _, field := range d.Fields {
 			stat := declType(g.Id(field.Name), field.Type)
@@ -43,22 +62,25 @@ func (d *Object) Gen(f *jen.File) error {
 	// third declare struct
 	f.Line().Comment(d.Comment)
 	f.Type().Id(d.Name).StructFunc(fieldsDecl)
-
-	// forth declare definitions
-	for _, def := range d.Definitions {
-		if err := def.Gen(f); err != nil {
-			return errorst.Wrap(err, "failed to generate definition<%s>", def)
-		}
+	if d.TableName != "" {
+		f.Line().Func().Params(jen.Id(d.Name)).Id("TableName").Params().String().Block(
+			jen.Return(jen.Lit(d.TableName)),
+		)
 	}
-
-	// fifth declare sub relations
-	for _, sub := range d.SubRelations {
-		if err := sub.Gen(f); err != nil {
-			return errorst.Wrap(err, "failed to generate sub relation<%s>", sub)
-		}
+	switch d.KeyStrategy {
+	case KeyStrategyUUID:
+		declKeyHook(f, d.Name, jen.Qual(uuidDomain, "Nil"), jen.Qual(uuidDomain, "New").Call())
+	case KeyStrategyULID:
+		f.ImportName(ulidDomain, "ulid")
+		declKeyHook(f, d.Name, jen.Lit(""), jen.Qual(ulidDomain, "Make").Call().Dot("String").Call())
+	}
+	if d.Discriminator != nil {
+		f.Line().Func().Params(jen.Id("o").Op("*").Id(d.Name)).Id("BeforeSave").
+			Params(jen.Op("*").Qual("gorm.io/gorm", "DB")).Error().Block(
+			jen.Id("o").Dot(d.Discriminator.Field).Op("=").Lit(d.Discriminator.Value),
+			jen.Return(jen.Nil()),
+		)
 	}
-
-	return nil
 }
 
 func (d *Alias) Gen(f *jen.File) error {
@@ -90,6 +112,9 @@ func declType(s *jen.Statement, typ Type) *jen.Statement {
 
 	if typ.IsArray {
 		s.Index()
+		for i := 1; i < typ.Dims; i++ {
+			s.Index()
+		}
 	} else if typ.NilAble {
 		s.Op("*")
 	}
@@ -101,6 +126,13 @@ func declType(s *jen.Statement, typ Type) *jen.Statement {
 	}
 }
 
-func declGormModel(g *jen.Group) *jen.Statement {
-	return g.Id("").Qual("gorm.io/gorm", "Model")
+// declKeyHook declares a BeforeCreate hook setting ID to newKey if it's zero.
+func declKeyHook(f *jen.File, name string, zero jen.Code, newKey jen.Code) {
+	f.Line().Func().Params(jen.Id("o").Op("*").Id(name)).Id("BeforeCreate").
+		Params(jen.Op("*").Qual("gorm.io/gorm", "DB")).Error().Block(
+		jen.If(jen.Id("o").Dot("ID").Op("==").Add(zero)).Block(
+			jen.Id("o").Dot("ID").Op("=").Add(newKey),
+		),
+		jen.Return(jen.Nil()),
+	)
 }
@@ -0,0 +1,87 @@
+package modelgen
+
+import (
+	"bytes"
+	"dbgen/pkg/schemas"
+	"github.com/dave/jennifer/jen"
+	"github.com/thorn-jmh/errorst"
+)
+
+// >>>>>>>>>>>> generator turns schemas into Go files, reusable and safe for concurrent use >>>>>>>>>>>>>>>
+
+// GeneratedHeader is the header comment of generated files.
+const GeneratedHeader = "Code generated by dbgen. DO NOT EDIT."
+
+// generatedMark is how generated files are recognized, in the first line.
+const generatedMark = "Code generated by dbgen"
+
+// Generator generates Go files from schemas. Once configured, a generator
+// keeps no state between calls, so it can be reused and called from
+// multiple goroutines. Passes and hooks must not be changed meanwhile.
+type Generator struct {
+	*Pipeline
+	Package string // package name of generated files
+}
+
+// NewGenerator returns a generator with default passes.
+func NewGenerator(pkg string, opts *Options) *Generator {
+	return &Generator{
+		Pipeline: NewPipeline(opts),
+		Package:  pkg,
+	}
+}
+
+// Generate returns the processed model of sch.
+func (g *Generator) Generate(sch *schemas.Schema) (*Object, error) {
+	return g.Run(sch)
+}
+
+// Render emits obj to a formatted Go file.
+func (g *Generator) Render(obj *Object) ([]byte, error) {
+	f := jen.NewFile(g.Package)
+	f.HeaderComment(GeneratedHeader)
+	if err := g.Emit(obj, f); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := f.Render(&buf); err != nil {
+		return nil, errorst.Wrap(err, "failed to render %s", obj.Name)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderFile emits declarations of a package file to a formatted Go file,
+// with BeforeEmit and AfterEmit hooks called on roots in the file.
+func (g *Generator) RenderFile(file PackageFile) ([]byte, error) {
+	f := jen.NewFile(g.Package)
+	f.HeaderComment(GeneratedHeader)
+	for _, root := range file.Roots {
+		if err := g.beforeEmit(root, f); err != nil {
+			return nil, err
+		}
+	}
+	for _, decl := range file.Decls {
+		if err := GenDecl(f, decl); err != nil {
+			return nil, errorst.Wrap(err, "failed to generate %s", declName(decl))
+		}
+	}
+	for _, root := range file.Roots {
+		if err := g.afterEmit(root, f); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := f.Render(&buf); err != nil {
+		return nil, errorst.Wrap(err, "failed to render %s", file.Name)
+	}
+	return buf.Bytes(), nil
+}
+
+// IsGenerated reports whether content is a file generated by dbgen,
+// which has the GeneratedHeader comment in the first line.
+func IsGenerated(content []byte) bool {
+	line, _, _ := bytes.Cut(content, []byte("\n"))
+	return bytes.Contains(line, []byte(generatedMark))
+}
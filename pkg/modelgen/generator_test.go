@@ -0,0 +1,86 @@
+package modelgen
+
+import (
+	"bytes"
+	"dbgen/pkg/schemas"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const orderSchema = `{
+	"$id": "Order",
+	"type": "object",
+	"properties": {
+		"no": {"type": "string", "x-unique": true},
+		"state": {"type": "string", "enum": ["open", "closed"]},
+		"items": {"type": "array", "items": {"$ref": "#/$defs/item"}},
+		"owner": {"$ref": "#/$defs/owner"}
+	},
+	"$defs": {
+		"item": {"type": "object", "properties": {"sku": {"type": "string"}, "count": {"type": "integer"}}},
+		"owner": {"type": "object", "properties": {"name": {"type": "string"}}}
+	}
+}`
+
+func generateCode(g *Generator, sch *schemas.Schema) ([]byte, error) {
+	obj, err := g.Generate(sch)
+	if err != nil {
+		return nil, err
+	}
+	return g.Render(obj)
+}
+
+// TestGeneratorConcurrent runs a generator from goroutines on a shared
+// schema and on distinct schemas, run it with -race.
+func TestGeneratorConcurrent(t *testing.T) {
+	const n = 16
+	g := NewGenerator("model", nil)
+	shared := mustSchema(t, orderSchema)
+	distinct := make([]*schemas.Schema, n)
+	for i := range distinct {
+		distinct[i] = mustSchema(t, strings.Replace(orderSchema, `"Order"`, fmt.Sprintf(`"Order%d"`, i), 1))
+	}
+
+	// expected outputs, generated sequentially
+	want, err := generateCode(g, shared)
+	if err != nil {
+		t.Fatalf("generate shared: %v", err)
+	}
+	wantDistinct := make([][]byte, n)
+	for i, sch := range distinct {
+		if wantDistinct[i], err = generateCode(g, sch); err != nil {
+			t.Fatalf("generate distinct %d: %v", i, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			got, err := generateCode(g, shared)
+			if err == nil && !bytes.Equal(got, want) {
+				err = fmt.Errorf("shared schema generated differently:\n%s", got)
+			}
+			errs <- err
+		}()
+		go func(i int) {
+			defer wg.Done()
+			got, err := generateCode(g, distinct[i])
+			if err == nil && !bytes.Equal(got, wantDistinct[i]) {
+				err = fmt.Errorf("schema %d generated differently:\n%s", i, got)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
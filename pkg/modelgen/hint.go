@@ -0,0 +1,72 @@
+package modelgen
+
+import "dbgen/pkg/schemas"
+
+// >>>>>>>>>>>> generator hints, written as `x-<name>` keywords in schema >>>>>>>>>>>>>>>
+
+const (
+	HintArrayStorage       = "array-storage"       // how to store arrays of non-object items
+	HintGoName             = "go-name"             // name of generated Go type
+	HintInheritance        = "inheritance"         // inheritance strategy of `oneOf` subtypes
+	HintDiscriminator      = "discriminator"       // discriminator column of inheritance
+	HintDiscriminatorValue = "discriminator-value" // discriminator value of a subtype
+	HintPolymorphic        = "polymorphic"         // polymorphic owner name of array items
+	HintPrimaryKey         = "primary-key"         // property is (part of) primary key
+	HintKeyStrategy        = "key-strategy"        // strategy of generated primary key
+	HintMixins             = "mixins"              // mixins applied to table
+	HintIndex              = "index"               // property is indexed
+	HintUnique             = "unique"              // property is unique indexed
+	HintIndexes            = "indexes"             // composite indexes of table
+	HintDeclared           = "declared"            // object is declared by another schema of the package
+)
+
+// array storage, value of HintArrayStorage
+const (
+	ArrayStorageJSON  = "json"  // store array in a json column
+	ArrayStorageTable = "table" // store array in child tables with an ordinal column
+)
+
+func getStringHint(sch *schemas.SubSchema, name string) string {
+	v, ok := sch.Extension(name)
+	if !ok {
+		return ""
+	}
+	s, _ := v.(string)
+	return s
+}
+
+func getBoolHint(sch *schemas.SubSchema, name string) bool {
+	v, ok := sch.Extension(name)
+	if !ok {
+		return false
+	}
+	b, _ := v.(bool)
+	return b
+}
+
+func getStringsHint(sch *schemas.SubSchema, name string) []string {
+	v, ok := sch.Extension(name)
+	if !ok {
+		return nil
+	}
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var ret []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				ret = append(ret, s)
+			}
+		}
+		return ret
+	}
+	return nil
+}
+
+func getArrayStorage(sch *schemas.SubSchema) string {
+	if storage := getStringHint(sch, HintArrayStorage); storage != "" {
+		return storage
+	}
+	return ArrayStorageJSON
+}
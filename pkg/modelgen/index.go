@@ -0,0 +1,239 @@
+package modelgen
+
+import (
+	"dbgen/pkg/schemas"
+	"encoding/json"
+	"fmt"
+	"github.com/thorn-jmh/errorst"
+	"strings"
+)
+
+// Index is an index of table.
+type Index struct {
+	Name    string        `json:"name"`             // index name, generated if empty
+	Columns []IndexColumn `json:"columns"`          // indexed columns in order
+	Unique  bool          `json:"unique,omitempty"` // is unique index
+	Where   string        `json:"where,omitempty"`  // condition of partial index
+	Type    string        `json:"type,omitempty"`   // index type, e.g. btree, hash
+}
+
+// IndexColumn is a column of index.
+type IndexColumn struct {
+	Field string `json:"field"`           // field name, property name before processed
+	Order string `json:"order,omitempty"` // sort order, asc or desc
+}
+
+// indexHint is an element of HintIndexes.
+type indexHint struct {
+	Name    string            `json:"name"`
+	Columns []json.RawMessage `json:"columns"` // "name", "name desc" or {"name": "name", "order": "desc"}
+	Unique  bool              `json:"unique"`
+	Where   string            `json:"where"`
+	Type    string            `json:"type"`
+}
+
+// getIndexes reads HintIndexes of an object schema.
+func getIndexes(sch *schemas.SubSchema) ([]Index, error) {
+	v, ok := sch.Extension(HintIndexes)
+	if !ok {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to read %s", HintIndexes)
+	}
+	var hints []indexHint
+	if err := json.Unmarshal(raw, &hints); err != nil {
+		return nil, errorst.Wrap(ErrWrongSyntax, "invalid %s: %v", HintIndexes, err)
+	}
+
+	var indexes []Index
+	for _, hint := range hints {
+		index := Index{
+			Name:   hint.Name,
+			Unique: hint.Unique,
+			Where:  hint.Where,
+			Type:   hint.Type,
+		}
+		for _, rawColumn := range hint.Columns {
+			column, err := parseIndexColumn(rawColumn)
+			if err != nil {
+				return nil, errorst.Wrap(err, "invalid column of index <%s>", hint.Name)
+			}
+			index.Columns = append(index.Columns, column)
+		}
+		if len(index.Columns) == 0 {
+			return nil, errorst.Wrap(ErrWrongSyntax, "index <%s> has no columns", hint.Name)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+func parseIndexColumn(raw json.RawMessage) (IndexColumn, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		words := strings.Fields(s)
+		if len(words) == 0 || len(words) > 2 {
+			return IndexColumn{}, errorst.Wrap(ErrWrongSyntax, "invalid index column: %s", s)
+		}
+		column := IndexColumn{Field: words[0]}
+		if len(words) == 2 {
+			column.Order = strings.ToLower(words[1])
+		}
+		return column, checkIndexOrder(column)
+	}
+
+	var obj struct {
+		Name  string `json:"name"`
+		Order string `json:"order"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil || obj.Name == "" {
+		return IndexColumn{}, errorst.Wrap(ErrWrongSyntax, "invalid index column: %s", raw)
+	}
+	column := IndexColumn{Field: obj.Name, Order: strings.ToLower(obj.Order)}
+	return column, checkIndexOrder(column)
+}
+
+func checkIndexOrder(column IndexColumn) error {
+	switch column.Order {
+	case "", "asc", "desc":
+		return nil
+	default:
+		return errorst.Wrap(ErrWrongSyntax, "invalid order <%s> of index column %s", column.Order, column.Field)
+	}
+}
+
+// getPropertyIndexes reads HintIndex and HintUnique of property schema,
+// the value is true, or an index name shared by columns of composite index.
+func getPropertyIndexes(sch *schemas.SubSchema, fieldName string) []Index {
+	var indexes []Index
+	for _, hint := range []string{HintIndex, HintUnique} {
+		v, ok := sch.Extension(hint)
+		if !ok {
+			continue
+		}
+		index := Index{
+			Columns: []IndexColumn{{Field: fieldName}},
+			Unique:  hint == HintUnique,
+		}
+		switch v := v.(type) {
+		case bool:
+			if !v {
+				continue
+			}
+		case string:
+			index.Name = v
+		default:
+			continue
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes
+}
+
+// ProcessIndexes checks indexes of tables, merges indexes
+// sharing the same name, and sets gorm tags of indexed fields.
+func ProcessIndexes(obj *Object) error {
+	if err := processIndexes(obj); err != nil {
+		return errorst.Wrap(err, "failed to process indexes of %s", obj.Name)
+	}
+
+	var err error
+	forEachTable(obj, func(sub *Object) {
+		if err == nil {
+			err = ProcessIndexes(sub)
+		}
+	})
+	return err
+}
+
+func processIndexes(obj *Object) error {
+	// first: merge indexes with the same name
+	var (
+		merged []Index
+		byName = make(map[string]int)
+	)
+	for _, index := range obj.Indexes {
+		if i, ok := byName[index.Name]; ok && index.Name != "" {
+			merged[i].Columns = append(merged[i].Columns, index.Columns...)
+			merged[i].Unique = merged[i].Unique || index.Unique
+			continue
+		}
+		byName[index.Name] = len(merged)
+		merged = append(merged, index)
+	}
+
+	// second: resolve columns and set tags
+	for i := range merged {
+		index := &merged[i]
+		var columns []string
+		for j := range index.Columns {
+			column := &index.Columns[j]
+			k := columnFieldIndex(obj, column.Field)
+			if k < 0 {
+				return errorst.Wrap(ErrWrongSyntax, "index <%s> references unknown column %s", index.Name, column.Field)
+			}
+			column.Field = obj.Fields[k].Name
+			columns = append(columns, SnakeStyle(column.Field))
+		}
+		if index.Name == "" {
+			index.Name = "idx_" + SnakeStyle(obj.Name) + "_" + strings.Join(columns, "_")
+		}
+
+		for j, column := range index.Columns {
+			k := fieldIndex(obj, column.Field)
+			addFieldGormTag(&obj.Fields[k], indexTag(index, j))
+		}
+	}
+	obj.Indexes = merged
+
+	return nil
+}
+
+// indexTag is the gorm tag of the j-th column of index.
+func indexTag(index *Index, j int) string {
+	key := "index"
+	if index.Unique {
+		key = "uniqueIndex"
+	}
+	settings := []string{index.Name}
+	if index.Type != "" {
+		settings = append(settings, "type:"+index.Type)
+	}
+	if index.Where != "" {
+		settings = append(settings, "where:"+index.Where)
+	}
+	if order := index.Columns[j].Order; order != "" {
+		settings = append(settings, "sort:"+order)
+	}
+	if len(index.Columns) > 1 {
+		settings = append(settings, fmt.Sprintf("priority:%d", j+1))
+	}
+	return key + ":" + strings.Join(settings, ",")
+}
+
+// columnFieldIndex finds a column field by its property name,
+// field name or column name.
+func columnFieldIndex(obj *Object, name string) int {
+	for i, field := range obj.Fields {
+		if !isColumnField(&field) {
+			continue
+		}
+		jsonName := strings.Split(field.Tags["json"], ",")[0]
+		if field.Name == name || jsonName == name || columnName(&field) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// columnName is the column name of field, from gorm tag or gorm naming.
+func columnName(field *Field) string {
+	for _, setting := range strings.Split(field.Tags["gorm"], ";") {
+		if strings.HasPrefix(setting, "column:") {
+			return strings.TrimPrefix(setting, "column:")
+		}
+	}
+	return SnakeStyle(field.Name)
+}
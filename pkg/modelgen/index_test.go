@@ -0,0 +1,110 @@
+package modelgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProcessIndexes(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   map[string]string // gorm tags of fields
+		err    string            // substring of error
+	}{
+		{
+			name: "property indexes",
+			schema: `{"$id": "Order", "type": "object", "properties": {
+				"sku": {"type": "string", "x-unique": true},
+				"status": {"type": "string", "x-index": true}
+			}}`,
+			want: map[string]string{
+				"Order.Sku":    "uniqueIndex:idx_order_sku",
+				"Order.Status": "index:idx_order_status",
+			},
+		},
+		{
+			name: "composite index by name",
+			schema: `{"$id": "Order", "type": "object", "properties": {
+				"customerId": {"type": "integer", "x-index": "idx_customer"},
+				"createdAt": {"type": "string", "format": "date-time", "x-index": "idx_customer"}
+			}}`,
+			want: map[string]string{
+				"Order.CreatedAt":  "index:idx_customer,priority:1",
+				"Order.CustomerID": "index:idx_customer,priority:2",
+			},
+		},
+		{
+			name: "table indexes",
+			schema: `{"$id": "Order", "type": "object", "properties": {
+				"sku": {"type": "string"},
+				"status": {"type": "string"},
+				"createdAt": {"type": "string", "format": "date-time"}
+			}, "x-indexes": [
+				{"columns": ["status", "createdAt desc"], "where": "status <> 'closed'", "type": "btree"},
+				{"name": "idx_sku", "unique": true, "columns": [{"name": "Sku", "order": "ASC"}]}
+			]}`,
+			want: map[string]string{
+				"Order.Status":    "index:idx_order_status_created_at,type:btree,where:status <> 'closed',priority:1",
+				"Order.CreatedAt": "index:idx_order_status_created_at,type:btree,where:status <> 'closed',sort:desc,priority:2",
+				"Order.Sku":       "uniqueIndex:idx_sku,sort:asc",
+			},
+		},
+		{
+			name: "indexes of child table",
+			schema: `{"$id": "Order", "type": "object", "properties": {
+				"lines": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "string", "x-index": true}}}}
+			}}`,
+			want: map[string]string{
+				"OrderLinesItem.Sku": "index:idx_order_lines_item_sku",
+			},
+		},
+		{
+			name: "unknown column",
+			schema: `{"$id": "Order", "type": "object", "properties": {"sku": {"type": "string"}},
+				"x-indexes": [{"name": "idx_code", "columns": ["code"]}]}`,
+			err: "index <idx_code> references unknown column code",
+		},
+		{
+			name: "relation is not a column",
+			schema: `{"$id": "Order", "type": "object", "properties": {
+				"lines": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "string"}}}}
+			}, "x-indexes": [{"name": "idx_lines", "columns": ["lines"]}]}`,
+			err: "index <idx_lines> references unknown column lines",
+		},
+		{
+			name: "invalid order",
+			schema: `{"$id": "Order", "type": "object", "properties": {"sku": {"type": "string"}},
+				"x-indexes": [{"name": "idx_sku", "columns": ["sku up"]}]}`,
+			err: "invalid order <up> of index column sku",
+		},
+		{
+			name: "no columns",
+			schema: `{"$id": "Order", "type": "object", "properties": {"sku": {"type": "string"}},
+				"x-indexes": [{"name": "idx_sku", "columns": []}]}`,
+			err: "index <idx_sku> has no columns",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := GenAndProcess(mustSchema(t, tt.schema), nil)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("GenAndProcess() = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenAndProcess() = %v", err)
+			}
+			tags := fieldTags(obj, nil)
+			for field, want := range tt.want {
+				if got, ok := tags[field]; !ok {
+					t.Errorf("no field %s in %v", field, tags)
+				} else if got != want {
+					t.Errorf("%s = %q, want %q", field, got, want)
+				}
+			}
+		})
+	}
+}
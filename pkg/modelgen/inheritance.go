@@ -0,0 +1,154 @@
+package modelgen
+
+import (
+	"dbgen/pkg/schemas"
+	"fmt"
+	"github.com/thorn-jmh/errorst"
+	"strconv"
+)
+
+// inheritance strategies, value of HintInheritance
+const (
+	// InheritanceSingleTable stores base and subtypes in one table,
+	// subtypes embed the base struct and share its table.
+	InheritanceSingleTable = "single-table"
+	// InheritanceTablePerType stores subtypes in their own tables,
+	// which have one foreign key to the base table.
+	InheritanceTablePerType = "table-per-type"
+)
+
+// DefaultDiscriminator is the discriminator column of inheritance
+// if HintDiscriminator is not given.
+const DefaultDiscriminator = "type"
+
+// Discriminator tells which subtype a row of inheritance table is.
+type Discriminator struct {
+	Field string `json:"field"` // name of discriminator field
+	Value string `json:"value"` // discriminator value of this subtype
+}
+
+// generateSubtypes generates `oneOf` subtypes of base object obj
+// with the given inheritance strategy.
+func generateSubtypes(ctx Context, sch *schemas.SubSchema, obj *Object, inheritance string) error {
+	if inheritance != InheritanceSingleTable && inheritance != InheritanceTablePerType {
+		return errorst.Wrap(ErrWrongSyntax, "invalid inheritance <%s> at %s", inheritance, ctx.Path)
+	}
+	obj.Inheritance = inheritance
+	obj.Origin.AddRule("base of %s inheritance by %s%s", inheritance, schemas.ExtensionPrefix, HintInheritance)
+
+	// flatten base first, so that fields can be compared
+	if err := ProcessTree(obj); err != nil {
+		return errorst.Wrap(err, "failed to process base <%s>", obj.Name)
+	}
+
+	// first: add discriminator column to base, unless declared in properties
+	discriminator := getStringHint(sch, HintDiscriminator)
+	if discriminator == "" {
+		discriminator = DefaultDiscriminator
+	}
+	discField := ctx.Naming.Fields.Format(discriminator)
+	if fieldIndex(obj, discField) < 0 {
+		field := Field{
+			Name:    discField,
+			Type:    Type{Name: "string"},
+			Tags:    make(map[string]string),
+			Comment: "discriminator of " + obj.Name,
+			Origin:  &Origin{Rules: []string{"discriminator of " + inheritance + " inheritance"}},
+		}
+		setFieldJsonTag(&field, discriminator)
+		obj.Fields = append(obj.Fields, field)
+	}
+
+	// second: generate subtypes
+	for i, subSch := range sch.OneOf {
+		subName := subtypeName(subSch, i)
+		subObj, err := GenerateObject(ctx.WithState(State{
+			Require: true,
+			Path:    ctx.Path + "/" + subName,
+			Pointer: ctx.Pointer + "/oneOf/" + strconv.Itoa(i),
+			Inline:  true, // subtypes embed base, they are not shared
+		}), subSch)
+		if err != nil {
+			return errorst.Wrap(err, "failed to generate subtype <%s> at %s", subName, ctx.Path)
+		}
+		if subObj.recursive {
+			return errorst.Wrap(ErrWrongSyntax, "subtype <%s> is a recursive $ref at %s", subName, ctx.Path)
+		}
+		if !isNamedObject(subObj) {
+			return errorst.Wrap(ErrWrongSyntax, "subtype <%s> is not an object at %s", subName, ctx.Path)
+		}
+
+		// fields of base are shared, not redeclared
+		if err := ProcessTree(subObj); err != nil {
+			return errorst.Wrap(err, "failed to process subtype <%s>", subObj.Name)
+		}
+		var fields []Field
+		for _, field := range subObj.Fields {
+			if fieldIndex(obj, field.Name) < 0 {
+				fields = append(fields, field)
+			}
+		}
+		subObj.Fields = fields
+		subObj.Origin.AddRule("subtype of %s by %s inheritance", obj.Name, inheritance)
+
+		switch inheritance {
+		case InheritanceSingleTable:
+			// embed base, and set discriminator before saving
+			base := Field{
+				Type:   Type{Name: obj.Name},
+				Origin: &Origin{Rules: []string{"embedded base of single-table inheritance"}},
+			}
+			subObj.Fields = append([]Field{base}, subObj.Fields...)
+			subObj.Discriminator = &Discriminator{
+				Field: discField,
+				Value: discriminatorValue(ctx, subSch, discriminator, subName),
+			}
+			obj.Definitions = append(obj.Definitions, subObj)
+		case InheritanceTablePerType:
+			// has one subtype
+			field := Field{
+				Name: ctx.Naming.Fields.Format(subName),
+				Type: Type{
+					Name:    subObj.Name,
+					NilAble: true,
+				},
+				Tags:    make(map[string]string),
+				Comment: fmt.Sprintf("subtype %s of %s", subObj.Name, obj.Name),
+				Origin:  &Origin{Pointer: ctx.Pointer + "/oneOf/" + strconv.Itoa(i)},
+			}
+			setFieldJsonTag(&field, subName)
+			field.Origin.AddRule("has one subtype by table-per-type inheritance")
+			obj.Fields = append(obj.Fields, field)
+			obj.SubRelations = append(obj.SubRelations, subObj)
+		}
+	}
+
+	return nil
+}
+
+// subtypeName names the i-th subtype by its title or $defs key.
+func subtypeName(sch *schemas.SubSchema, i int) string {
+	if sch.Title != "" {
+		return sch.Title
+	}
+	if sch.Ref != "" {
+		if defName, err := refDefName(sch.Ref); err == nil {
+			return defName
+		}
+	}
+	return strconv.Itoa(i)
+}
+
+// discriminatorValue is the HintDiscriminatorValue of subtype, the `const`
+// of discriminator property if declared, or the subtype name.
+func discriminatorValue(ctx Context, sch *schemas.SubSchema, discriminator string, subName string) string {
+	if value := getStringHint(sch, HintDiscriminatorValue); value != "" {
+		return value
+	}
+	if derefSch, err := derefSchema(ctx, sch); err == nil {
+		if prop, ok := derefSch.Properties[discriminator]; ok && prop.Const != nil {
+			return fmt.Sprint(prop.Const)
+		}
+	}
+	return subName
+}
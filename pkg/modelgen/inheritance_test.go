@@ -0,0 +1,121 @@
+package modelgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateSubtypes(t *testing.T) {
+	schema := func(inheritance string) string {
+		return `{"$id": "Pet", "type": "object", "x-inheritance": "` + inheritance + `", "x-discriminator": "kind",
+			"properties": {"name": {"type": "string"}},
+			"oneOf": [
+				{"$ref": "#/$defs/Dog", "x-discriminator-value": "woof"},
+				{"$ref": "#/$defs/Cat"},
+				{"title": "Bird", "type": "object", "properties": {"name": {"type": "string"}, "wings": {"type": "integer"}}}
+			],
+			"$defs": {
+				"Dog": {"type": "object", "properties": {"bark": {"type": "boolean"}}},
+				"Cat": {"type": "object", "properties": {"kind": {"type": "string", "const": "cat"}, "lives": {"type": "integer"}}}
+			}}`
+	}
+	tests := []struct {
+		inheritance    string
+		want           map[string]string // types and gorm tags of fields
+		absent         []string          // fields not generated
+		discriminators map[string]string // discriminator values of subtypes
+		err            string            // substring of error
+	}{
+		{
+			inheritance: InheritanceSingleTable,
+			want: map[string]string{
+				"Pet.Kind":      "string",
+				"Pet.Name":      "string",
+				"PetDog.":       "Pet",
+				"PetDog.Bark":   "bool",
+				"PetCat.":       "Pet",
+				"PetCat.Lives":  "int",
+				"PetBird.":      "Pet",
+				"PetBird.Wings": "int",
+			},
+			absent:         []string{"PetBird.Name", "PetCat.Kind", "PetDog.ID"},
+			discriminators: map[string]string{"PetDog": "woof", "PetCat": "cat", "PetBird": "Bird"},
+		},
+		{
+			inheritance: InheritanceTablePerType,
+			want: map[string]string{
+				"Pet.Kind":      "string",
+				"Pet.Dog":       "*PetDog",
+				"Pet.Cat":       "*PetCat",
+				"Pet.Bird":      "*PetBird",
+				"PetDog.PetID":  "uint",
+				"PetDog.Bark":   "bool",
+				"PetCat.PetID":  "uint",
+				"PetBird.Wings": "int",
+			},
+			absent:         []string{"PetBird.Name", "PetCat.Kind"},
+			discriminators: map[string]string{"PetDog": "", "PetCat": "", "PetBird": ""},
+		},
+		{
+			inheritance: "class-table",
+			err:         "invalid inheritance <class-table>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.inheritance, func(t *testing.T) {
+			obj, err := GenAndProcess(mustSchema(t, schema(tt.inheritance)), nil)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("GenAndProcess() = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenAndProcess() = %v", err)
+			}
+			if obj.Inheritance != tt.inheritance {
+				t.Errorf("Inheritance = %q, want %q", obj.Inheritance, tt.inheritance)
+			}
+			types := fieldTypes(obj, nil)
+			for field, want := range tt.want {
+				if got, ok := types[field]; !ok {
+					t.Errorf("no field %s in %v", field, types)
+				} else if got != want {
+					t.Errorf("%s = %q, want %q", field, got, want)
+				}
+			}
+			for _, field := range tt.absent {
+				if _, ok := types[field]; ok {
+					t.Errorf("field %s generated", field)
+				}
+			}
+
+			subtypes := make(map[string]*Object)
+			for _, def := range obj.Definitions {
+				if defObj, ok := def.(*Object); ok {
+					subtypes[defObj.Name] = defObj
+				}
+			}
+			for _, sub := range obj.SubRelations {
+				subtypes[sub.Name] = sub
+			}
+			for name, want := range tt.discriminators {
+				sub, ok := subtypes[name]
+				if !ok {
+					t.Errorf("no subtype %s", name)
+					continue
+				}
+				var got string
+				if sub.Discriminator != nil {
+					if sub.Discriminator.Field != "Kind" {
+						t.Errorf("%s discriminator field = %q, want Kind", name, sub.Discriminator.Field)
+					}
+					got = sub.Discriminator.Value
+				}
+				if got != want {
+					t.Errorf("%s discriminator = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package modelgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/thorn-jmh/errorst"
+	"math"
+	"strconv"
+)
+
+// >>>>>>>>>>>> IR is the JSON form of processed model, to be inspected or post-processed >>>>>>>>>>>>>>>
+
+// IRVersion is the version of IR format, bumped on incompatible changes.
+const IRVersion = 1
+
+// decl kinds in IR
+const (
+	DeclKindObject = "object"
+	DeclKindAlias  = "alias"
+	DeclKindEnum   = "enum"
+)
+
+// IR is the document of intermediate representation.
+type IR struct {
+	Version int     `json:"version"`
+	Model   *Object `json:"model"`
+}
+
+// irDecl is a Decl tagged with its kind.
+type irDecl struct {
+	Kind   string  `json:"kind"`
+	Object *Object `json:"object,omitempty"`
+	Alias  *Alias  `json:"alias,omitempty"`
+	Enum   *Enum   `json:"enum,omitempty"`
+}
+
+// MarshalIR dumps processed model as indented JSON.
+func MarshalIR(obj *Object) ([]byte, error) {
+	data, err := json.MarshalIndent(IR{Version: IRVersion, Model: obj}, "", "  ")
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to marshal IR")
+	}
+	return append(data, '\n'), nil
+}
+
+// UnmarshalIR loads model from JSON dumped by MarshalIR.
+func UnmarshalIR(data []byte) (*Object, error) {
+	var ir IR
+	if err := json.Unmarshal(data, &ir); err != nil {
+		return nil, errorst.Wrap(err, "failed to unmarshal IR")
+	}
+	if ir.Version != IRVersion {
+		return nil, errorst.Wrap(ErrWrongSyntax, "unsupported IR version %d, want %d", ir.Version, IRVersion)
+	}
+	if ir.Model == nil {
+		return nil, errorst.Wrap(ErrWrongSyntax, "IR without model")
+	}
+	return ir.Model, nil
+}
+
+// type alias for marshal
+type objectToMarshal Object
+
+// MarshalJSON implements json.Marshaler for Object,
+// definitions are tagged with their kinds.
+func (d *Object) MarshalJSON() ([]byte, error) {
+	var defs []irDecl
+	for _, def := range d.Definitions {
+		switch def := def.(type) {
+		case *Object:
+			defs = append(defs, irDecl{Kind: DeclKindObject, Object: def})
+		case *Alias:
+			defs = append(defs, irDecl{Kind: DeclKindAlias, Alias: def})
+		case *Enum:
+			defs = append(defs, irDecl{Kind: DeclKindEnum, Enum: def})
+		default:
+			return nil, errorst.Wrap(ErrInvalidStructure, "unknown definition %T of %s", def, d.Name)
+		}
+	}
+
+	return json.Marshal(struct {
+		*objectToMarshal
+		Definitions []irDecl `json:"definitions,omitempty"`
+	}{
+		objectToMarshal: (*objectToMarshal)(d),
+		Definitions:     defs,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Object.
+func (d *Object) UnmarshalJSON(data []byte) error {
+	var obj struct {
+		*objectToMarshal
+		Definitions []irDecl `json:"definitions,omitempty"`
+	}
+	obj.objectToMarshal = (*objectToMarshal)(d)
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	d.Definitions = nil
+	for _, def := range obj.Definitions {
+		switch {
+		case def.Kind == DeclKindObject && def.Object != nil:
+			d.Definitions = append(d.Definitions, def.Object)
+		case def.Kind == DeclKindAlias && def.Alias != nil:
+			d.Definitions = append(d.Definitions, def.Alias)
+		case def.Kind == DeclKindEnum && def.Enum != nil:
+			d.Definitions = append(d.Definitions, def.Enum)
+		default:
+			return errorst.Wrap(ErrWrongSyntax, "invalid definition of kind <%s> in %s", def.Kind, d.Name)
+		}
+	}
+	return nil
+}
+
+// type alias for unmarshal
+type enumToUnmarshal Enum
+
+// UnmarshalJSON implements json.Unmarshaler for Enum,
+// integer values are kept integers rather than float64.
+func (d *Enum) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode((*enumToUnmarshal)(d)); err != nil {
+		return err
+	}
+	for i, v := range d.Values {
+		d.Values[i] = enumValue(v)
+	}
+	return nil
+}
+
+// enumValue normalizes JSON numbers of enum value v, integers are int
+// and others are float64, so that it's the same after an IR round trip.
+func enumValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := strconv.Atoi(v.String()); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return enumValue(f)
+		}
+		return v.String()
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,105 @@
+package modelgen
+
+import (
+	"bytes"
+	"dbgen/pkg/schemas"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIRRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{
+			name: "enums",
+			schema: `{
+				"$id": "Order",
+				"type": "object",
+				"properties": {
+					"level": {"type": "integer", "enum": [1, 2, 3]},
+					"kind": {"type": "string", "enum": ["a", "b"]}
+				}
+			}`,
+		},
+		{
+			name: "relations",
+			schema: `{
+				"$id": "Order",
+				"type": "object",
+				"properties": {
+					"items": {"type": "array", "items": {"$ref": "#/$defs/item"}},
+					"owner": {"$ref": "#/$defs/owner"}
+				},
+				"$defs": {
+					"item": {"type": "object", "properties": {"sku": {"type": "string"}}},
+					"owner": {"type": "object", "properties": {"name": {"type": "string"}}}
+				}
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sch, err := schemas.FromJSON(strings.NewReader(tt.schema))
+			if err != nil {
+				t.Fatalf("FromJSON: %v", err)
+			}
+			g := NewGenerator("model", nil)
+			obj, err := g.Generate(sch)
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+
+			data, err := MarshalIR(obj)
+			if err != nil {
+				t.Fatalf("MarshalIR: %v", err)
+			}
+			loaded, err := UnmarshalIR(data)
+			if err != nil {
+				t.Fatalf("UnmarshalIR: %v", err)
+			}
+			again, err := MarshalIR(loaded)
+			if err != nil {
+				t.Fatalf("MarshalIR again: %v", err)
+			}
+			if !bytes.Equal(data, again) {
+				t.Errorf("IR changed after round trip:\n%s\nwant:\n%s", again, data)
+			}
+
+			want, err := g.Render(obj)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			got, err := g.Render(loaded)
+			if err != nil {
+				t.Fatalf("Render loaded: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("code changed after round trip:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestIREnumValues(t *testing.T) {
+	data := []byte(`{"version": 1, "model": {"name": "Order", "definitions": [
+		{"kind": "enum", "enum": {"name": "Level", "baseType": {"name": "int"}, "values": [1, 2, 9007199254740993]}},
+		{"kind": "enum", "enum": {"name": "Ratio", "baseType": {"name": "float64"}, "values": [0.5, 2.0]}}
+	]}}`)
+	obj, err := UnmarshalIR(data)
+	if err != nil {
+		t.Fatalf("UnmarshalIR: %v", err)
+	}
+	want := [][]any{{1, 2, 9007199254740993}, {0.5, 2}}
+	for i, def := range obj.Definitions {
+		enum, ok := def.(*Enum)
+		if !ok {
+			t.Fatalf("definition %d is %T, want *Enum", i, def)
+		}
+		if !reflect.DeepEqual(enum.Values, want[i]) {
+			t.Errorf("values of %s = %#v, want %#v", enum.Name, enum.Values, want[i])
+		}
+	}
+}
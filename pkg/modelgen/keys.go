@@ -0,0 +1,96 @@
+package modelgen
+
+import (
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+// KeyStrategy decides the type and generation of primary keys added to tables.
+type KeyStrategy string
+
+const (
+	KeyStrategyAuto KeyStrategy = "auto" // auto increment uint
+	KeyStrategyUUID KeyStrategy = "uuid" // uuid.UUID, generated before create
+	KeyStrategyULID KeyStrategy = "ulid" // ULID string, generated before create
+)
+
+const (
+	uuidDomain = "github.com/google/uuid"
+	ulidDomain = "github.com/oklog/ulid/v2"
+)
+
+// processPrimaryKey makes sure table obj has primary keys.
+// Properties marked with HintPrimaryKey are natural keys, a property
+// named ID is used as key too, else ID is generated by key strategy.
+func processPrimaryKey(obj *Object, keyStrategy KeyStrategy) {
+	if len(primaryKeys(obj)) > 0 {
+		obj.KeyStrategy = ""
+		return
+	}
+	if i := fieldIndex(obj, "ID"); i >= 0 {
+		// use the existing ID field as primary key
+		addFieldGormTag(&obj.Fields[i], "primaryKey")
+		obj.Fields[i].Origin.AddRule("primary key by name ID")
+		obj.KeyStrategy = ""
+		return
+	}
+
+	if obj.KeyStrategy == "" {
+		obj.KeyStrategy = keyStrategy
+	}
+	idField := Field{
+		Name: "ID",
+		Tags: map[string]string{
+			"json": "-",
+		},
+		Origin: &Origin{},
+	}
+	switch obj.KeyStrategy {
+	case KeyStrategyUUID:
+		idField.Type = Type{Name: "UUID", Domain: uuidDomain}
+		idField.Tags["gorm"] = "primaryKey;type:uuid"
+	case KeyStrategyULID:
+		idField.Type = Type{Name: "string"}
+		idField.Tags["gorm"] = "primaryKey;size:26"
+	default:
+		if obj.KeyStrategy != KeyStrategyAuto {
+			logrus.Warnf("unknown key strategy %s of %s, use %s", obj.KeyStrategy, obj.Name, KeyStrategyAuto)
+			obj.KeyStrategy = KeyStrategyAuto
+		}
+		idField.Type = Type{Name: "uint"}
+		idField.Tags["gorm"] = "primaryKey"
+	}
+	idField.Origin.AddRule("primary key added by association, key strategy %s", obj.KeyStrategy)
+	obj.Fields = append(obj.Fields, idField)
+}
+
+// primaryKeys returns primary key fields of table obj in order.
+func primaryKeys(obj *Object) []Field {
+	var keys []Field
+	for _, field := range obj.Fields {
+		for _, setting := range strings.Split(field.Tags["gorm"], ";") {
+			if setting == "primaryKey" {
+				keys = append(keys, field)
+			}
+		}
+	}
+	return keys
+}
+
+// foreignKeyType is the type of foreign key referencing a key of keyType.
+func foreignKeyType(keyType Type) Type {
+	return Type{
+		Name:   keyType.Name,
+		Domain: keyType.Domain,
+	}
+}
+
+// relationFieldIndex returns index of the field in obj referencing sub.
+func relationFieldIndex(obj *Object, sub *Object) int {
+	for i, field := range obj.Fields {
+		if field.Type.Name == sub.Name && field.Type.Domain == "" {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,102 @@
+package modelgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcessPrimaryKey(t *testing.T) {
+	field := func(name, typ, gorm string) Field {
+		return Field{Name: name, Type: Type{Name: typ}, Tags: map[string]string{"gorm": gorm}}
+	}
+	tests := []struct {
+		name     string
+		fields   []Field
+		strategy KeyStrategy // strategy of object, empty if not set
+		want     []string    // primary keys like "ID uint primaryKey"
+		wantKS   KeyStrategy // strategy of object after
+	}{
+		{
+			name:   "natural key",
+			fields: []Field{field("Code", "string", "primaryKey"), field("Name", "string", "")},
+			want:   []string{"Code string primaryKey"},
+		},
+		{
+			name:   "composite key",
+			fields: []Field{field("Line", "int", "primaryKey"), field("Code", "string", "size:8;primaryKey")},
+			want:   []string{"Line int primaryKey", "Code string size:8;primaryKey"},
+		},
+		{
+			name:   "field named ID",
+			fields: []Field{field("ID", "int64", "")},
+			want:   []string{"ID int64 primaryKey"},
+		},
+		{
+			name:   "auto",
+			fields: []Field{field("Name", "string", "")},
+			want:   []string{"ID uint primaryKey"},
+			wantKS: KeyStrategyAuto,
+		},
+		{
+			name:     "uuid",
+			strategy: KeyStrategyUUID,
+			want:     []string{"ID " + uuidDomain + ".UUID primaryKey;type:uuid"},
+			wantKS:   KeyStrategyUUID,
+		},
+		{
+			name:     "ulid",
+			strategy: KeyStrategyULID,
+			want:     []string{"ID string primaryKey;size:26"},
+			wantKS:   KeyStrategyULID,
+		},
+		{
+			name:     "natural key over strategy",
+			fields:   []Field{field("Code", "string", "primaryKey")},
+			strategy: KeyStrategyUUID,
+			want:     []string{"Code string primaryKey"},
+		},
+		{
+			name:     "unknown strategy",
+			strategy: "serial",
+			want:     []string{"ID uint primaryKey"},
+			wantKS:   KeyStrategyAuto,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &Object{Name: "Order", Fields: tt.fields, KeyStrategy: tt.strategy}
+			processPrimaryKey(obj, KeyStrategyAuto)
+			var got []string
+			for _, key := range primaryKeys(obj) {
+				typ := key.Type.Name
+				if key.Type.Domain != "" {
+					typ = key.Type.Domain + "." + typ
+				}
+				got = append(got, strings.Join([]string{key.Name, typ, key.Tags["gorm"]}, " "))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("primary keys = %q, want %q", got, tt.want)
+			}
+			if obj.KeyStrategy != tt.wantKS {
+				t.Errorf("KeyStrategy = %q, want %q", obj.KeyStrategy, tt.wantKS)
+			}
+		})
+	}
+}
+
+func TestForeignKeyType(t *testing.T) {
+	tests := []struct {
+		key  Type
+		want Type
+	}{
+		{Type{Name: "uint"}, Type{Name: "uint"}},
+		{Type{Name: "UUID", Domain: uuidDomain}, Type{Name: "UUID", Domain: uuidDomain}},
+		{Type{Name: "string", NilAble: true}, Type{Name: "string"}},
+	}
+	for _, tt := range tests {
+		if got := foreignKeyType(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("foreignKeyType(%v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package modelgen
+
+import "github.com/sirupsen/logrus"
+
+// built-in mixins
+const (
+	MixinGormModel  = "gorm.Model"  // ID, CreatedAt, UpdatedAt, DeletedAt like gorm.Model
+	MixinTimestamps = "timestamps"  // CreatedAt, UpdatedAt
+	MixinSoftDelete = "soft-delete" // DeletedAt
+	MixinAudit      = "audit"       // CreatedAt, UpdatedAt, CreatedBy, UpdatedBy
+)
+
+var (
+	createdAtField = mixinField("CreatedAt", Type{Name: "Time", Domain: "time"}, "")
+	updatedAtField = mixinField("UpdatedAt", Type{Name: "Time", Domain: "time"}, "")
+	deletedAtField = mixinField("DeletedAt", Type{Name: "DeletedAt", Domain: "gorm.io/gorm"}, "index")
+	createdByField = mixinField("CreatedBy", Type{Name: "string"}, "")
+	updatedByField = mixinField("UpdatedBy", Type{Name: "string"}, "")
+)
+
+// BuiltinMixins are named field sets which can be applied to tables,
+// fields without json tag are tagged by ProcessMixins.
+var BuiltinMixins = map[string][]Field{
+	MixinGormModel: {
+		mixinField("ID", Type{Name: "uint"}, "primaryKey"),
+		createdAtField,
+		updatedAtField,
+		deletedAtField,
+	},
+	MixinTimestamps: {createdAtField, updatedAtField},
+	MixinSoftDelete: {deletedAtField},
+	MixinAudit:      {createdAtField, updatedAtField, createdByField, updatedByField},
+}
+
+// MixinOptions decide which mixins are applied to which tables.
+type MixinOptions struct {
+	Sets map[string][]Field // custom mixins, which override built-in ones
+	Root []string           // mixins applied to the root table
+	All  []string           // mixins applied to all tables
+}
+
+// ProcessMixins adds mixin fields to tables, the mixins are
+// from options and HintMixins of each table. Fields colliding
+// with existing ones are skipped. Json names of fields without
+// json tag follow the column style of naming, or camel if unset.
+func ProcessMixins(obj *Object, opts MixinOptions, naming Naming) {
+	jsonStyle := naming.Columns
+	if jsonStyle == nil {
+		jsonStyle = NewCamelStyle(naming.Initialisms...)
+	}
+	processMixins(obj, opts, jsonStyle, true)
+}
+
+func processMixins(obj *Object, opts MixinOptions, jsonStyle NameStyle, isRoot bool) {
+	// first: collect mixins of this table
+	var names []string
+	if isRoot {
+		names = append(names, opts.Root...)
+	}
+	names = append(names, opts.All...)
+	names = append(names, obj.Mixins...)
+
+	// second: add fields
+	applied := make(map[string]bool) // applied mixins
+	added := make(map[string]bool)   // added fields, mixins may overlap
+	for _, name := range names {
+		if applied[name] {
+			continue
+		}
+		applied[name] = true
+
+		fields, ok := opts.Sets[name]
+		if !ok {
+			fields, ok = BuiltinMixins[name]
+		}
+		if !ok {
+			logrus.Warnf("unknown mixin %s of %s, skipped", name, obj.Name)
+			continue
+		}
+
+		for _, field := range fields {
+			if field.Name != "" && fieldIndex(obj, field.Name) >= 0 {
+				if !added[field.Name] {
+					logrus.Warnf("mixin field %s of %s collides with property, skipped", field.Name, obj.Name)
+				}
+				continue
+			}
+			field = copyField(field)
+			if _, ok := field.Tags["json"]; !ok && field.Name != "" {
+				setFieldJsonTag(&field, jsonStyle.Format(field.Name))
+			}
+			field.Origin = &Origin{Rules: []string{"mixin " + name}}
+			obj.Fields = append(obj.Fields, field)
+			added[field.Name] = true
+		}
+	}
+
+	// third: go on with sub tables
+	forEachTable(obj, func(sub *Object) {
+		processMixins(sub, opts, jsonStyle, false)
+	})
+}
+
+// forEachTable calls fn on each sub relation of obj,
+// including sub relations of definitions.
+func forEachTable(obj *Object, fn func(sub *Object)) {
+	for _, def := range obj.Definitions {
+		if defObj, ok := def.(*Object); ok {
+			forEachTable(defObj, fn)
+		}
+	}
+	for _, sub := range obj.SubRelations {
+		fn(sub)
+	}
+}
+
+func mixinField(name string, typ Type, gormTag string) Field {
+	field := Field{
+		Name: name,
+		Type: typ,
+		Tags: make(map[string]string),
+	}
+	if gormTag != "" {
+		field.Tags["gorm"] = gormTag
+	}
+	return field
+}
+
+func copyField(field Field) Field {
+	tags := make(map[string]string, len(field.Tags))
+	for k, v := range field.Tags {
+		tags[k] = v
+	}
+	field.Tags = tags
+	return field
+}
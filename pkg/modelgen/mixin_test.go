@@ -0,0 +1,153 @@
+package modelgen
+
+import (
+	"bytes"
+	"github.com/dave/jennifer/jen"
+	"strings"
+	"testing"
+)
+
+const mixinSchema = `{"$id": "Order", "type": "object", "properties": {
+	"no": {"type": "string"},
+	"createdBy": {"type": "integer"},
+	"lines": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "string"}}}},
+	"notes": {"type": "array", "items": {"type": "object", "x-mixins": "audit", "properties": {"text": {"type": "string"}}}}
+}}`
+
+// fieldJSONTags returns json tags of fields in the tree of obj, by `Object.Field`.
+func fieldJSONTags(obj *Object, tags map[string]string) map[string]string {
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	for _, field := range obj.Fields {
+		tags[obj.Name+"."+field.Name] = field.Tags["json"]
+	}
+	for _, def := range obj.Definitions {
+		if defObj, ok := def.(*Object); ok {
+			fieldJSONTags(defObj, tags)
+		}
+	}
+	for _, sub := range obj.SubRelations {
+		fieldJSONTags(sub, tags)
+	}
+	return tags
+}
+
+func TestProcessMixins(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   MixinOptions
+		naming Naming
+		want   map[string]string // types and gorm tags of fields
+		json   map[string]string // json tags of fields
+		absent []string          // fields not added
+	}{
+		{
+			name: "root timestamps",
+			opts: MixinOptions{Root: []string{MixinTimestamps}},
+			want: map[string]string{
+				"Order.CreatedAt": "time.Time",
+				"Order.UpdatedAt": "time.Time",
+			},
+			json: map[string]string{
+				"Order.CreatedAt": "createdAt",
+			},
+			absent: []string{"OrderLinesItem.CreatedAt"},
+		},
+		{
+			name: "all soft delete",
+			opts: MixinOptions{All: []string{MixinSoftDelete}},
+			want: map[string]string{
+				"Order.DeletedAt":          "gorm.io/gorm.DeletedAt index",
+				"OrderLinesItem.DeletedAt": "gorm.io/gorm.DeletedAt index",
+			},
+		},
+		{
+			name: "hint and collision with property",
+			want: map[string]string{
+				"OrderNotesItem.CreatedBy": "string",
+				"Order.CreatedBy":          "int",
+			},
+			absent: []string{"Order.UpdatedBy"},
+		},
+		{
+			name:   "json names by column style",
+			opts:   MixinOptions{Root: []string{MixinGormModel, MixinAudit}},
+			naming: Naming{Columns: SnakeStyle},
+			want: map[string]string{
+				"Order.ID":        "uint primaryKey",
+				"Order.CreatedBy": "int",
+			},
+			json: map[string]string{
+				"Order.ID":        "id",
+				"Order.DeletedAt": "deleted_at",
+				"Order.UpdatedBy": "updated_by",
+			},
+		},
+		{
+			name: "custom set overrides built-in",
+			opts: MixinOptions{Root: []string{MixinTimestamps}, Sets: map[string][]Field{
+				MixinTimestamps: {{Name: "Created", Type: Type{Name: "int64"}, Tags: map[string]string{"json": "created", "gorm": "autoCreateTime"}}},
+			}},
+			want: map[string]string{
+				"Order.Created": "int64 autoCreateTime",
+			},
+			json: map[string]string{
+				"Order.Created": "created",
+			},
+			absent: []string{"Order.CreatedAt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := GenerateModel(mustSchema(t, mixinSchema), DefaultOptions())
+			if err != nil {
+				t.Fatalf("GenerateModel() = %v", err)
+			}
+			if err := ProcessTree(obj); err != nil {
+				t.Fatalf("ProcessTree() = %v", err)
+			}
+			ProcessMixins(obj, tt.opts, tt.naming)
+
+			types := fieldTypes(obj, nil)
+			for field, want := range tt.want {
+				if got, ok := types[field]; !ok {
+					t.Errorf("no field %s in %v", field, types)
+				} else if got != want {
+					t.Errorf("%s = %q, want %q", field, got, want)
+				}
+			}
+			for _, field := range tt.absent {
+				if _, ok := types[field]; ok {
+					t.Errorf("unexpected field %s", field)
+				}
+			}
+			tags := fieldJSONTags(obj, nil)
+			for field, want := range tt.json {
+				if got := tags[field]; got != want {
+					t.Errorf("json tag of %s = %q, want %q", field, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMixinImports(t *testing.T) {
+	obj, err := GenAndProcess(mustSchema(t, mixinSchema), &Options{Mixins: MixinOptions{Root: []string{MixinGormModel}}})
+	if err != nil {
+		t.Fatalf("GenAndProcess() = %v", err)
+	}
+	f := jen.NewFile("model")
+	if err := obj.Gen(f); err != nil {
+		t.Fatalf("Gen() = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := f.Render(&buf); err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+	for _, want := range []string{`gorm "gorm.io/gorm"`, `"time"`, "gorm.DeletedAt", "time.Time"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("generated code has no %s:\n%s", want, buf.String())
+		}
+	}
+}
@@ -1,39 +1,62 @@
 package modelgen
 
 // >>>>>>>>>>>> this used to describe the result of model generation >>>>>>>>>>>>>>>
+// NOTE: json tags are the format of IR, see ir.go
 
 type Object struct {
 	// meta data
-	Name    string // struct type's name
-	Comment string // struct type's comment
+	Name        string      `json:"name"`                  // struct type's name
+	Comment     string      `json:"comment,omitempty"`     // struct type's comment
+	TableName   string      `json:"tableName,omitempty"`   // table name, empty to leave it to gorm
+	KeyStrategy KeyStrategy `json:"keyStrategy,omitempty"` // strategy of generated primary key
+	Mixins      []string    `json:"mixins,omitempty"`      // mixins applied to this table
+	Indexes     []Index     `json:"indexes,omitempty"`     // indexes of this table
 	// fields
-	Fields []Field // fields of struct type
+	Fields []Field `json:"fields,omitempty"` // fields of struct type
 	// tree structure
-	Definitions  []Decl
-	SubRelations []*Object
+	Definitions  []Decl    `json:"-"` // see Object.MarshalJSON
+	SubRelations []*Object `json:"subRelations,omitempty"`
+	// relations
+	Inheritance   string         `json:"inheritance,omitempty"`   // inheritance strategy if this is a base type
+	Discriminator *Discriminator `json:"discriminator,omitempty"` // discriminator if this is a single-table subtype
+	Polymorphic   string         `json:"polymorphic,omitempty"`   // polymorphic owner name if this is a polymorphic child
+	// explanation
+	Origin *Origin `json:"-"` // where it is generated from, see explain.go
+	// recursive is a reference to an ancestor object, never in the tree,
+	// or to an object declared by another schema if external is set
+	recursive bool
+	external  bool
 }
 
 type Field struct {
-	Name    string            // field name
-	Type    Type              // field Type
-	Tags    map[string]string // tags of this field
-	Comment string            // comment on this field
+	Name    string            `json:"name"`              // field name
+	Type    Type              `json:"type"`              // field Type
+	Tags    map[string]string `json:"tags,omitempty"`    // tags of this field
+	Comment string            `json:"comment,omitempty"` // comment on this field
+	Origin  *Origin           `json:"-"`                 // where it is generated from, see explain.go
+	// recursive refers to an ancestor object by recursive $ref,
+	// which is resolved to a relation by ProcessAssociation, or
+	// to an object declared by another schema if external is set
+	recursive bool
+	external  bool
 }
 
 type Alias struct {
-	Name     string // alias type's name
-	Comment  string // alias type's comment
-	BaseType Type   // alias type's base type
+	Name     string  `json:"name"`              // alias type's name
+	Comment  string  `json:"comment,omitempty"` // alias type's comment
+	BaseType Type    `json:"baseType"`          // alias type's base type
+	Origin   *Origin `json:"-"`                 // where it is generated from, see explain.go
 }
 
 type Enum struct {
 	Alias
-	Values []any
+	Values []any `json:"values"`
 }
 
 type Type struct {
-	Name    string // type Name
-	Domain  string // package path
-	NilAble bool   // is NilAble, we will use pointer to represent NilAble type
-	IsArray bool
+	Name    string `json:"name"`              // type Name
+	Domain  string `json:"domain,omitempty"`  // package path
+	NilAble bool   `json:"nilAble,omitempty"` // is NilAble, we will use pointer to represent NilAble type
+	IsArray bool   `json:"isArray,omitempty"`
+	Dims    int    `json:"dims,omitempty"` // dimensions of array type, 0 is treated as 1 when IsArray
 }
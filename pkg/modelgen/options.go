@@ -0,0 +1,102 @@
+package modelgen
+
+import (
+	"github.com/thorn-jmh/errorst"
+	"go/token"
+	"strings"
+)
+
+// Options are options of model generation.
+type Options struct {
+	Naming      Naming      // name styles of generated outputs
+	NamePolicy  NamePolicy  // how to name generated types
+	KeyStrategy KeyStrategy // strategy of generated primary keys, see KeyStrategyAuto
+	Mixins      MixinOptions
+	TypeMapping map[string]Type // Go types of primitives by "type:format" or "type", e.g. "string:uuid"
+}
+
+// NamePolicy decides where type names come from.
+type NamePolicy string
+
+const (
+	// NamePolicyPath concatenates schema name and every path fragment,
+	// e.g. `OrderLinesItem`.
+	NamePolicyPath NamePolicy = "path"
+	// NamePolicyShort prefers title, then $defs key, then property name,
+	// and falls back to NamePolicyPath on collision.
+	NamePolicyShort NamePolicy = "short"
+)
+
+// Naming holds name styles of each kind of output.
+type Naming struct {
+	Types   NameStyle // style of type names
+	Fields  NameStyle // style of struct field names
+	Columns NameStyle // style of column names, nil to leave it to gorm
+	Tables  NameStyle // style of table names, nil to leave it to gorm
+	// Initialisms are extra initialisms of the styles, used by templates
+	Initialisms []string
+}
+
+// DefaultOptions returns the default options.
+func DefaultOptions() *Options {
+	return &Options{
+		Naming: Naming{
+			Types:  BigCamelStyle,
+			Fields: BigCamelStyle,
+		},
+		NamePolicy:  NamePolicyPath,
+		KeyStrategy: KeyStrategyAuto,
+	}
+}
+
+// complete fills empty options with default values.
+func (o *Options) complete() *Options {
+	def := DefaultOptions()
+	if o == nil {
+		return def
+	}
+
+	opts := *o
+	if opts.Naming.Types == nil {
+		opts.Naming.Types = def.Naming.Types
+	}
+	if opts.Naming.Fields == nil {
+		opts.Naming.Fields = def.Naming.Fields
+	}
+	if opts.NamePolicy == "" {
+		opts.NamePolicy = def.NamePolicy
+	}
+	if opts.KeyStrategy == "" {
+		opts.KeyStrategy = def.KeyStrategy
+	}
+	return &opts
+}
+
+// ParseGoType parses a qualified Go type like `github.com/google/uuid.UUID`,
+// or a predeclared type like `int64`.
+func ParseGoType(s string) (Type, error) {
+	i := strings.LastIndex(s, ".")
+	if i < 0 {
+		if !token.IsIdentifier(s) {
+			return Type{}, errorst.Wrap(ErrWrongSyntax, "invalid go type: %s", s)
+		}
+		return Type{Name: s}, nil
+	}
+
+	typ := Type{Domain: s[:i], Name: s[i+1:]}
+	if typ.Domain == "" || strings.HasSuffix(typ.Domain, "/") || !token.IsIdentifier(typ.Name) {
+		return Type{}, errorst.Wrap(ErrWrongSyntax, "invalid go type: %s", s)
+	}
+	return typ, nil
+}
+
+// mappedType returns the Go type mapped by format or type of sch.
+func (o *Options) mappedType(typeName string, format string) (Type, bool) {
+	if format != "" {
+		if typ, ok := o.TypeMapping[typeName+":"+format]; ok {
+			return typ, true
+		}
+	}
+	typ, ok := o.TypeMapping[typeName]
+	return typ, ok
+}
@@ -0,0 +1,266 @@
+package modelgen
+
+import (
+	"encoding/json"
+	"github.com/dave/jennifer/jen"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
+// >>>>>>>>>>>> package combines models of schemas generated into the same Go package >>>>>>>>>>>>>>>
+
+// SharedFile is the file of declarations shared by models of a package.
+const SharedFile = "models.go"
+
+// Package is models of schemas in one Go package, each type is declared once.
+// Identical declarations are shared, and conflicting ones are renamed.
+type Package struct {
+	Models  []*Object // root objects of schemas in order
+	Shared  []Decl    // declarations used by more than one model
+	Own     [][]Decl  // declarations used only by each model, in order of Models
+	Renames []Rename  // declarations renamed on conflict
+}
+
+// PackageFile is a file of a package, with its declarations.
+type PackageFile struct {
+	Name  string
+	Decls []Decl
+	Roots []*Object // models whose root objects are declared in this file
+}
+
+// packageDecl is a declaration seen in the package.
+type packageDecl struct {
+	decl        Decl
+	fingerprint string
+	owners      []int // indexes of models using it
+}
+
+// NewPackage combines models, models are renamed in place on conflict.
+func NewPackage(models []*Object) *Package {
+	var (
+		p     = &Package{Models: models, Own: make([][]Decl, len(models))}
+		seen  = make(map[string]*packageDecl)
+		order []string // names in order of first seen
+	)
+	for i, model := range models {
+		decls := FlattenDecls(model)
+
+		// first: rename conflicting declarations, until referring
+		// declarations are also renamed
+		for changed := true; changed; {
+			changed = false
+			for _, decl := range decls {
+				name := declName(decl)
+				if prev, ok := seen[name]; ok && prev.fingerprint != declFingerprint(decl) {
+					newName := availableName(name, seen, decls)
+					renameDecl(decls, name, newName)
+					p.Renames = append(p.Renames, Rename{Path: model.Name, From: name, To: newName})
+					changed = true
+				}
+			}
+		}
+
+		// second: share identical declarations
+		for _, decl := range decls {
+			name := declName(decl)
+			if prev, ok := seen[name]; ok {
+				prev.owners = append(prev.owners, i)
+				continue
+			}
+			seen[name] = &packageDecl{decl: decl, fingerprint: declFingerprint(decl), owners: []int{i}}
+			order = append(order, name)
+		}
+	}
+
+	for _, name := range order {
+		if decl := seen[name]; len(decl.owners) > 1 {
+			p.Shared = append(p.Shared, decl.decl)
+		} else {
+			p.Own[decl.owners[0]] = append(p.Own[decl.owners[0]], decl.decl)
+		}
+	}
+	for _, rename := range p.Renames {
+		logrus.Warnf("type %s of %s conflicts with another schema in package, renamed to %s", rename.From, rename.Path, rename.To)
+	}
+	return p
+}
+
+// Files lays out declarations in files, SharedFile and a file per model,
+// or a file per type if split.
+func (p *Package) Files(split bool) []PackageFile {
+	roots := make(map[Decl]*Object, len(p.Models))
+	for _, model := range p.Models {
+		roots[model] = model
+	}
+
+	var files []PackageFile
+	addFile := func(name string, decls []Decl) {
+		file := PackageFile{Name: name, Decls: decls}
+		for _, decl := range decls {
+			if root, ok := roots[decl]; ok {
+				file.Roots = append(file.Roots, root)
+			}
+		}
+		files = append(files, file)
+	}
+
+	if split {
+		for _, decls := range append([][]Decl{p.Shared}, p.Own...) {
+			for _, decl := range decls {
+				addFile(declName(decl)+".go", []Decl{decl})
+			}
+		}
+		return files
+	}
+
+	if len(p.Shared) > 0 {
+		addFile(SharedFile, p.Shared)
+	}
+	for i, decls := range p.Own {
+		if len(decls) > 0 {
+			addFile(p.Models[i].Name+".go", decls)
+		}
+	}
+	return files
+}
+
+// FlattenDecls returns obj and all declarations in its tree, in the
+// order they are generated by Object.Gen.
+func FlattenDecls(obj *Object) []Decl {
+	decls := []Decl{obj}
+	for _, def := range obj.Definitions {
+		if defObj, ok := def.(*Object); ok {
+			decls = append(decls, FlattenDecls(defObj)...)
+		} else {
+			decls = append(decls, def)
+		}
+	}
+	for _, sub := range obj.SubRelations {
+		decls = append(decls, FlattenDecls(sub)...)
+	}
+	return decls
+}
+
+// GenDecl declares decl alone, without the tree of an object.
+func GenDecl(f *jen.File, decl Decl) error {
+	if obj, ok := decl.(*Object); ok {
+		obj.genType(f)
+		return nil
+	}
+	return decl.Gen(f)
+}
+
+func declName(decl Decl) string {
+	switch decl := decl.(type) {
+	case *Object:
+		return decl.Name
+	case *Alias:
+		return decl.Name
+	case *Enum:
+		return decl.Name
+	}
+	return ""
+}
+
+// declFingerprint identifies the content of decl, not including its tree.
+func declFingerprint(decl Decl) string {
+	var v any = decl
+	if obj, ok := decl.(*Object); ok {
+		shallow := *obj
+		shallow.Definitions, shallow.SubRelations = nil, nil
+		v = &shallow
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// availableName returns name with the least numeric suffix, which is
+// neither seen in package nor declared in decls.
+func availableName(name string, seen map[string]*packageDecl, decls []Decl) string {
+	taken := func(n string) bool {
+		if _, ok := seen[n]; ok {
+			return true
+		}
+		for _, decl := range decls {
+			if declName(decl) == n {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 2; ; i++ {
+		if n := name + strconv.Itoa(i); !taken(n) {
+			return n
+		}
+	}
+}
+
+// renameDecl renames declaration from to to, and references to it in decls,
+// which are types and foreign keys named after it by association.
+func renameDecl(decls []Decl, from string, to string) {
+	renameType := func(typ *Type) {
+		if typ.Domain == "" && typ.Name == from {
+			typ.Name = to
+		}
+	}
+	foreignKeys := make(map[string]string)
+	for _, decl := range decls {
+		if obj, ok := decl.(*Object); ok && obj.Name == from {
+			for _, key := range primaryKeys(obj) {
+				foreignKeys[from+key.Name] = to + key.Name
+			}
+		}
+	}
+
+	for _, decl := range decls {
+		if declName(decl) == from {
+			originOf(decl).AddRule("renamed from %s on conflict in package", from)
+		}
+		switch decl := decl.(type) {
+		case *Object:
+			if decl.Name == from {
+				decl.Name = to
+			}
+			for i := range decl.Fields {
+				field := &decl.Fields[i]
+				renameType(&field.Type)
+				if newName, ok := foreignKeys[field.Name]; ok && field.Tags["json"] == "-" {
+					field.Name = newName
+				}
+				if gormTag, ok := field.Tags["gorm"]; ok {
+					field.Tags["gorm"] = renameForeignKeys(gormTag, foreignKeys)
+				}
+			}
+		case *Alias:
+			if decl.Name == from {
+				decl.Name = to
+			}
+			renameType(&decl.BaseType)
+		case *Enum:
+			if decl.Name == from {
+				decl.Name = to
+			}
+			renameType(&decl.BaseType)
+		}
+	}
+}
+
+// renameForeignKeys renames fields in `foreignKey` of gormTag.
+func renameForeignKeys(gormTag string, foreignKeys map[string]string) string {
+	settings := strings.Split(gormTag, ";")
+	for i, setting := range settings {
+		names, ok := strings.CutPrefix(setting, "foreignKey:")
+		if !ok {
+			continue
+		}
+		list := strings.Split(names, ",")
+		for j, name := range list {
+			if newName, ok := foreignKeys[name]; ok {
+				list[j] = newName
+			}
+		}
+		settings[i] = "foreignKey:" + strings.Join(list, ",")
+	}
+	return strings.Join(settings, ";")
+}
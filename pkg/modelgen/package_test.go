@@ -0,0 +1,66 @@
+package modelgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewPackage(t *testing.T) {
+	schema := func(id, itemProps string) string {
+		return `{"$id": "` + id + `", "type": "object", "properties": {
+			"items": {"type": "array", "items": {"title": "Item", "type": "object", "properties": {` + itemProps + `,
+				"tags": {"type": "array", "items": {"title": "Tag", "type": "object", "properties": {"name": {"type": "string"}}}}
+			}}},
+			"status": {"title": "Status", "type": "string", "enum": ["open", "closed"]}
+		}}`
+	}
+	opts := &Options{NamePolicy: NamePolicyShort}
+	var models []*Object
+	for _, sch := range []string{
+		schema("Order", `"sku": {"type": "string"}`),
+		schema("Invoice", `"line": {"type": "integer", "x-primary-key": true}, "code": {"type": "string", "x-primary-key": true}`),
+	} {
+		model, err := GenAndProcess(mustSchema(t, sch), opts)
+		if err != nil {
+			t.Fatalf("GenAndProcess() = %v", err)
+		}
+		models = append(models, model)
+	}
+	p := NewPackage(models)
+
+	var shared []string
+	for _, decl := range p.Shared {
+		shared = append(shared, declName(decl))
+	}
+	if got, want := strings.Join(shared, ","), "Status"; got != want {
+		t.Errorf("shared = %s, want %s", got, want)
+	}
+	var renames []string
+	for _, rename := range p.Renames {
+		renames = append(renames, rename.From+"->"+rename.To)
+	}
+	if got, want := strings.Join(renames, ","), "Item->Item2,Tag->Tag2"; got != want {
+		t.Errorf("renames = %s, want %s", got, want)
+	}
+
+	// foreign keys named after the renamed type follow it
+	types := fieldTypes(models[1], nil)
+	for field, want := range map[string]string{
+		"Invoice.ItemsItems": "[]Item2",
+		"Item2.TagsItems":    "[]Tag2 foreignKey:Item2Code,Item2Line;references:Code,Line",
+		"Tag2.Item2Code":     "string",
+		"Tag2.Item2Line":     "int",
+	} {
+		if got, ok := types[field]; !ok {
+			t.Errorf("no field %s in %v", field, types)
+		} else if got != want {
+			t.Errorf("%s = %q, want %q", field, got, want)
+		}
+	}
+	if _, ok := types["Tag2.ItemCode"]; ok {
+		t.Errorf("foreign key ItemCode is not renamed: %v", types)
+	}
+	if _, ok := fieldTypes(models[0], nil)["Tag.ItemID"]; !ok {
+		t.Errorf("foreign key ItemID of the first model is renamed")
+	}
+}
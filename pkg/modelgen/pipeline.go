@@ -0,0 +1,242 @@
+package modelgen
+
+import (
+	"dbgen/pkg/schemas"
+	"github.com/dave/jennifer/jen"
+	"github.com/thorn-jmh/errorst"
+)
+
+// >>>>>>>>>>>> pipeline runs generation passes, with hooks registered by library users >>>>>>>>>>>>>>>
+
+// names of default passes, in order
+const (
+	PassTree        = "tree"        // flatten unnamed objects
+	PassMixins      = "mixins"      // add mixin fields
+	PassAssociation = "association" // add primary keys and foreign keys
+	PassNaming      = "naming"      // apply table and column name styles
+	PassHooks       = "hooks"       // call OnObject and OnField hooks
+	PassIndexes     = "indexes"     // check indexes and set index tags
+)
+
+// Pass is a named step processing the generated model in place.
+type Pass struct {
+	Name string
+	Run  func(opts *Options, obj *Object) error
+}
+
+// OnSchemaLoaded is called with the schema before generation.
+type OnSchemaLoaded interface {
+	OnSchemaLoaded(sch *schemas.Schema) error
+}
+
+// OnObject is called with each struct type in PassHooks, after keys,
+// foreign keys and names are settled, so final tags are seen. Fields
+// added by hooks are not styled by PassNaming.
+type OnObject interface {
+	OnObject(obj *Object, isTable bool) error
+}
+
+// OnField is called with each field of struct types in PassHooks,
+// after OnObject of the same hook.
+type OnField interface {
+	OnField(obj *Object, field *Field) error
+}
+
+// BeforeEmit is called before the model is emitted to file.
+type BeforeEmit interface {
+	BeforeEmit(obj *Object, f *jen.File) error
+}
+
+// AfterEmit is called after the model is emitted to file.
+type AfterEmit interface {
+	AfterEmit(obj *Object, f *jen.File) error
+}
+
+// Pipeline generates model from schema by ordered passes.
+type Pipeline struct {
+	Options *Options
+	passes  []Pass
+	hooks   []any
+}
+
+// NewPipeline returns a pipeline with default passes.
+func NewPipeline(opts *Options) *Pipeline {
+	p := &Pipeline{Options: opts.complete()}
+	p.passes = []Pass{
+		{Name: PassTree, Run: func(_ *Options, obj *Object) error {
+			return ProcessTree(obj)
+		}},
+		{Name: PassMixins, Run: func(opts *Options, obj *Object) error {
+			ProcessMixins(obj, opts.Mixins, opts.Naming)
+			return nil
+		}},
+		{Name: PassAssociation, Run: func(opts *Options, obj *Object) error {
+			ProcessAssociation(obj, obj, opts.KeyStrategy)
+			return nil
+		}},
+		{Name: PassNaming, Run: func(opts *Options, obj *Object) error {
+			ProcessNaming(obj, opts.Naming)
+			return nil
+		}},
+		{Name: PassHooks, Run: func(_ *Options, obj *Object) error {
+			return p.runObjectHooks(obj, true)
+		}},
+		{Name: PassIndexes, Run: func(_ *Options, obj *Object) error {
+			return ProcessIndexes(obj)
+		}},
+	}
+	return p
+}
+
+// Passes returns names of passes in order.
+func (p *Pipeline) Passes() []string {
+	names := make([]string, 0, len(p.passes))
+	for _, pass := range p.passes {
+		names = append(names, pass.Name)
+	}
+	return names
+}
+
+// AddPass appends pass to the end.
+func (p *Pipeline) AddPass(pass Pass) {
+	p.passes = append(p.passes, pass)
+}
+
+// InsertPass inserts pass after the pass named after,
+// or at the beginning if after is empty.
+func (p *Pipeline) InsertPass(after string, pass Pass) error {
+	i := 0
+	if after != "" {
+		if i = p.passIndex(after); i < 0 {
+			return errorst.Wrap(ErrWrongSyntax, "no pass named %s", after)
+		}
+		i++
+	}
+	p.passes = append(p.passes[:i], append([]Pass{pass}, p.passes[i:]...)...)
+	return nil
+}
+
+// RemovePass removes the pass named name.
+func (p *Pipeline) RemovePass(name string) error {
+	i := p.passIndex(name)
+	if i < 0 {
+		return errorst.Wrap(ErrWrongSyntax, "no pass named %s", name)
+	}
+	p.passes = append(p.passes[:i], p.passes[i+1:]...)
+	return nil
+}
+
+// Use registers hooks, each implements at least one of OnSchemaLoaded,
+// OnObject, OnField, BeforeEmit and AfterEmit. Hooks are called in order.
+func (p *Pipeline) Use(hooks ...any) error {
+	for _, hook := range hooks {
+		switch hook.(type) {
+		case OnSchemaLoaded, OnObject, OnField, BeforeEmit, AfterEmit:
+			p.hooks = append(p.hooks, hook)
+		default:
+			return errorst.Wrap(ErrWrongSyntax, "%T implements no hook", hook)
+		}
+	}
+	return nil
+}
+
+// Run generates model from sch, then runs all passes.
+func (p *Pipeline) Run(sch *schemas.Schema) (*Object, error) {
+	// first: schema hooks
+	for _, hook := range p.hooks {
+		if h, ok := hook.(OnSchemaLoaded); ok {
+			if err := h.OnSchemaLoaded(sch); err != nil {
+				return nil, errorst.Wrap(err, "failed to run OnSchemaLoaded hook %T", hook)
+			}
+		}
+	}
+
+	// second: generate object
+	obj, err := GenerateModel(sch, p.Options)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to generate model")
+	}
+
+	// third: process object
+	for _, pass := range p.passes {
+		if err := pass.Run(p.Options, obj); err != nil {
+			return nil, errorst.Wrap(err, "failed to run pass %s", pass.Name)
+		}
+	}
+
+	return obj, nil
+}
+
+// Emit writes obj to f, with BeforeEmit and AfterEmit hooks.
+func (p *Pipeline) Emit(obj *Object, f *jen.File) error {
+	if err := p.beforeEmit(obj, f); err != nil {
+		return err
+	}
+	if err := obj.Gen(f); err != nil {
+		return err
+	}
+	return p.afterEmit(obj, f)
+}
+
+func (p *Pipeline) beforeEmit(obj *Object, f *jen.File) error {
+	for _, hook := range p.hooks {
+		if h, ok := hook.(BeforeEmit); ok {
+			if err := h.BeforeEmit(obj, f); err != nil {
+				return errorst.Wrap(err, "failed to run BeforeEmit hook %T", hook)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Pipeline) afterEmit(obj *Object, f *jen.File) error {
+	for _, hook := range p.hooks {
+		if h, ok := hook.(AfterEmit); ok {
+			if err := h.AfterEmit(obj, f); err != nil {
+				return errorst.Wrap(err, "failed to run AfterEmit hook %T", hook)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Pipeline) passIndex(name string) int {
+	for i, pass := range p.passes {
+		if pass.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// runObjectHooks calls OnObject and OnField hooks on obj and its children.
+func (p *Pipeline) runObjectHooks(obj *Object, isTable bool) error {
+	for _, hook := range p.hooks {
+		if h, ok := hook.(OnObject); ok {
+			if err := h.OnObject(obj, isTable); err != nil {
+				return errorst.Wrap(err, "failed to run OnObject hook %T on %s", hook, obj.Name)
+			}
+		}
+		if h, ok := hook.(OnField); ok {
+			for i := range obj.Fields {
+				if err := h.OnField(obj, &obj.Fields[i]); err != nil {
+					return errorst.Wrap(err, "failed to run OnField hook %T on %s.%s", hook, obj.Name, obj.Fields[i].Name)
+				}
+			}
+		}
+	}
+
+	for _, def := range obj.Definitions {
+		if defObj, ok := def.(*Object); ok {
+			if err := p.runObjectHooks(defObj, false); err != nil {
+				return err
+			}
+		}
+	}
+	for _, sub := range obj.SubRelations {
+		if err := p.runObjectHooks(sub, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package modelgen
+
+import (
+	"dbgen/pkg/schemas"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fieldRecorder records tags of fields seen by OnField.
+type fieldRecorder struct {
+	tags map[string]map[string]string
+}
+
+func (r *fieldRecorder) OnField(obj *Object, field *Field) error {
+	r.tags[obj.Name+"."+field.Name] = field.Tags
+	return nil
+}
+
+func TestPipelinePasses(t *testing.T) {
+	want := []string{PassTree, PassMixins, PassAssociation, PassNaming, PassHooks, PassIndexes}
+	if got := NewPipeline(nil).Passes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Passes() = %v, want %v", got, want)
+	}
+}
+
+func TestPipelineHooksSeeFinalModel(t *testing.T) {
+	sch, err := schemas.FromJSON(strings.NewReader(`{
+		"$id": "Order",
+		"type": "object",
+		"properties": {
+			"items": {"type": "array", "items": {"$ref": "#/$defs/item"}}
+		},
+		"$defs": {
+			"item": {"type": "object", "properties": {"sku": {"type": "string"}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("FromJSON: %v", err)
+	}
+	opts := &Options{}
+	opts.Naming.Columns = SnakeStyle
+	p := NewPipeline(opts)
+	rec := &fieldRecorder{tags: make(map[string]map[string]string)}
+	if err := p.Use(rec); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	if _, err := p.Run(sch); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if _, ok := rec.tags["Order.ID"]; !ok {
+		t.Errorf("hooks did not see primary key of Order, seen %v", rec.tags)
+	}
+	if _, ok := rec.tags["OrderItemsItem.OrderID"]; !ok {
+		t.Errorf("hooks did not see foreign key of OrderItemsItem, seen %v", rec.tags)
+	}
+	if tags := rec.tags["OrderItemsItem.Sku"]; !strings.Contains(tags["gorm"], "column:sku") {
+		t.Errorf("hooks saw tags %v of OrderItemsItem.Sku, want column name", tags)
+	}
+}
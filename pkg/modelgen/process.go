@@ -2,72 +2,245 @@ package modelgen
 
 import (
 	"dbgen/pkg/schemas"
+	"github.com/sirupsen/logrus"
 	"github.com/thorn-jmh/errorst"
 	"net/url"
+	"sort"
 	"strings"
 )
 
-var MainSchema *schemas.Schema
+// GenAndProcess generates model from sch by the default pipeline.
+func GenAndProcess(sch *schemas.Schema, opts *Options) (*Object, error) {
+	return NewPipeline(opts).Run(sch)
+}
 
-func GenAndProcess(sch *schemas.Schema) (*Object, error) {
-	// first: generate object
-	obj, err := GenerateModel(sch)
-	if err != nil {
-		return nil, errorst.Wrap(err, "failed to generate model")
+// ProcessAssociation adds primary keys and foreign keys,
+// table is the object owning the table which obj belongs to,
+// keyStrategy is used when the table has no key strategy hint.
+func ProcessAssociation(obj *Object, table *Object, keyStrategy KeyStrategy) {
+	processAssociation(obj, table, keyStrategy, nil)
+}
+
+// processAssociation is ProcessAssociation with tables from the root
+// to the table of obj, which recursive fields may refer to.
+func processAssociation(obj *Object, table *Object, keyStrategy KeyStrategy, tables []*Object) {
+	// if obj is the table, this is a database schema
+	// add primary key
+	if obj == table {
+		processPrimaryKey(obj, keyStrategy)
+		tables = append(tables[:len(tables):len(tables)], table)
 	}
+	processRecursion(obj, table, tables)
 
-	// second: process object
-	err = ProcessTree(obj)
-	if err != nil {
-		return nil, errorst.Wrap(err, "failed to process model")
+	for _, def := range obj.Definitions {
+		if defObj, ok := def.(*Object); ok {
+			processAssociation(defObj, table, keyStrategy, tables)
+		}
 	}
 
-	// third: process association
-	ProcessAssociation(obj, obj.Name)
+	// add association foreignKey to obj
+	keys := primaryKeys(table)
+	for _, sub := range obj.SubRelations {
+		if sub.Polymorphic != "" {
+			// add polymorphic id and type to subRelation
+			sub.Fields = append(sub.Fields, Field{
+				Name: sub.Polymorphic + "ID",
+				Type: foreignKeyType(keys[0].Type),
+				Tags: map[string]string{
+					"json": "-",
+				},
+				Comment: "polymorphic foreign key to owner",
+				Origin:  &Origin{Rules: []string{"polymorphic foreign key added by association"}},
+			}, Field{
+				Name: sub.Polymorphic + "Type",
+				Type: Type{
+					Name: "string",
+				},
+				Tags: map[string]string{
+					"json": "-",
+				},
+				Comment: "polymorphic type of owner",
+				Origin:  &Origin{Rules: []string{"polymorphic type added by association"}},
+			})
+		} else {
+			// add association foreignKey to subRelation, one for each key
+			var foreignKeys, references []string
+			for _, key := range keys {
+				foreignKeyField := Field{
+					Name: table.Name + key.Name,
+					Type: foreignKeyType(key.Type),
+					Tags: map[string]string{
+						"json": "-",
+					},
+					Comment: "foreign key to " + table.Name,
+					Origin:  &Origin{Rules: []string{"foreign key to " + table.Name + " added by association"}},
+				}
+				sub.Fields = append(sub.Fields, foreignKeyField)
+				foreignKeys = append(foreignKeys, foreignKeyField.Name)
+				references = append(references, key.Name)
+			}
+
+			// composite keys can not be guessed by gorm
+			if i := relationFieldIndex(obj, sub); i >= 0 && len(keys) > 1 {
+				addFieldGormTag(&obj.Fields[i], "foreignKey:"+strings.Join(foreignKeys, ","))
+				addFieldGormTag(&obj.Fields[i], "references:"+strings.Join(references, ","))
+			}
+		}
 
-	return obj, nil
+		processAssociation(sub, sub, keyStrategy, tables)
+	}
 }
 
-func ProcessAssociation(obj *Object, parentName string) {
-	// if Name equal to parent name, this is a database schema
-	// add ID field
-	if obj.Name == parentName {
-		// add ID field to root obj
-		idField := Field{
-			Name: "ID",
-			Type: Type{
-				Name: "uint",
-			},
-			Tags: map[string]string{
-				"json": "-",
-				"gorm": "primaryKey",
-			},
+// processRecursion turns recursive fields of obj in table into relations
+// to the ancestor tables they refer to: arrays have many of the ancestor by
+// a nullable foreign key in it, others belong to the ancestor by a nullable
+// foreign key in obj. A tree whose parent and children refer to the table
+// itself share one foreign key. Recursive fields of structs which are not
+// tables, and those referring to ancestors which are not tables, are stored
+// in json, as foreign keys of them would relate the wrong tables.
+func processRecursion(obj *Object, table *Object, tables []*Object) {
+	// foreign keys are added after the loop, target may be obj
+	type foreignKey struct {
+		owner *Object
+		field Field
+		self  bool // references the table owning it
+	}
+	var foreignKeys []*foreignKey
+	pending := func(owner *Object, name string) *foreignKey {
+		for _, fk := range foreignKeys {
+			if fk.owner == owner && fk.field.Name == name {
+				return fk
+			}
 		}
-		obj.Fields = append(obj.Fields, idField)
+		return nil
 	}
 
-	for _, def := range obj.Definitions {
-		if defObj, ok := def.(*Object); ok {
-			ProcessAssociation(defObj, parentName)
+	for i := range obj.Fields {
+		field := &obj.Fields[i]
+		if !field.recursive {
+			continue
+		}
+		if field.external {
+			addFieldGormTag(field, "serializer:json")
+			field.Origin.AddRule("stored in json, %s is declared by another schema", field.Type.Name)
+			continue
+		}
+		if obj != table {
+			logrus.Warnf("recursive field %s of %s refers to %s, but %s is not a table, stored in json", field.Name, obj.Name, field.Type.Name, obj.Name)
+			addFieldGormTag(field, "serializer:json")
+			field.Origin.AddRule("stored in json, %s is not a table", obj.Name)
+			continue
+		}
+		var target *Object
+		for _, t := range tables {
+			if t.Name == field.Type.Name {
+				target = t
+			}
 		}
+		if target == nil {
+			logrus.Warnf("recursive field %s of %s refers to %s, which is not a table, stored in json", field.Name, obj.Name, field.Type.Name)
+			addFieldGormTag(field, "serializer:json")
+			field.Origin.AddRule("stored in json, %s is not a table", field.Type.Name)
+			continue
+		}
+
+		// has many: foreign key to table in target,
+		// belongs to: foreign key to target in obj
+		var (
+			owner, referenced = obj, target
+			prefix            = field.Name
+			self              = target == table
+		)
+		if field.Type.IsArray {
+			owner, referenced, prefix = target, table, table.Name
+			if target == table {
+				prefix = "Parent"
+			}
+		}
+		keys := primaryKeys(referenced)
+		for _, key := range keys {
+			if fk := pending(owner, prefix+key.Name); fk != nil && fk.self && self {
+				continue
+			}
+			if pending(owner, prefix+key.Name) != nil || fieldIndex(owner, prefix+key.Name) >= 0 {
+				prefix = field.Name + prefix
+				break
+			}
+		}
+
+		var names, references []string
+		for _, key := range keys {
+			name := prefix + key.Name
+			if pending(owner, name) == nil {
+				foreignKeys = append(foreignKeys, &foreignKey{owner, recursiveForeignKey(name, key, referenced, field.Name), self})
+			}
+			names = append(names, name)
+			references = append(references, key.Name)
+		}
+		addFieldGormTag(field, "foreignKey:"+strings.Join(names, ","))
+		addFieldGormTag(field, "references:"+strings.Join(references, ","))
+		field.Origin.AddRule("recursive relation to %s by foreign key %s", target.Name, strings.Join(names, ", "))
+	}
+	for _, fk := range foreignKeys {
+		fk.owner.Fields = append(fk.owner.Fields, fk.field)
 	}
+}
 
-	// add association foreignKey to obj
-	for _, sub := range obj.SubRelations {
-		// add association foreignKey to subRelation
-		foreignKeyField := Field{
-			Name: parentName + "ID",
-			Type: Type{
-				Name: "uint",
-			},
-			Tags: map[string]string{
-				"json": "-",
-			},
-			Comment: "foreign key to " + obj.Name,
+// recursiveForeignKey is the nullable foreign key name referencing key of
+// table by recursive field, roots of recursion have no parent.
+func recursiveForeignKey(name string, key Field, table *Object, field string) Field {
+	typ := foreignKeyType(key.Type)
+	typ.NilAble = true
+	return Field{
+		Name: name,
+		Type: typ,
+		Tags: map[string]string{
+			"json": "-",
+		},
+		Comment: "foreign key to " + table.Name + " of " + field,
+		Origin:  &Origin{Rules: []string{"foreign key of recursive " + field + " added by association"}},
+	}
+}
+
+// ProcessNaming applies database name styles to tables and columns.
+func ProcessNaming(obj *Object, naming Naming) {
+	processNaming(obj, naming, true)
+}
+
+func processNaming(obj *Object, naming Naming, isTable bool) {
+	if isTable && naming.Tables != nil {
+		obj.TableName = naming.Tables.Format(obj.Name)
+	}
+	if obj.Inheritance == InheritanceSingleTable && obj.TableName == "" {
+		// subtypes need a table name to share
+		obj.TableName = SnakeStyle(obj.Name)
+	}
+
+	if naming.Columns != nil {
+		for i := range obj.Fields {
+			field := &obj.Fields[i]
+			if !isColumnField(field) {
+				continue
+			}
+			name := field.Name
+			if jsonName := strings.Split(field.Tags["json"], ",")[0]; jsonName != "" && jsonName != "-" {
+				name = jsonName
+			}
+			addFieldGormTag(field, "column:"+naming.Columns.Format(name))
+			field.Origin.AddRule("column named by naming style")
 		}
-		sub.Fields = append(sub.Fields, foreignKeyField)
+	}
 
-		ProcessAssociation(sub, sub.Name)
+	for _, def := range obj.Definitions {
+		if defObj, ok := def.(*Object); ok {
+			processNaming(defObj, naming, false)
+			if defObj.Discriminator != nil && obj.Inheritance == InheritanceSingleTable {
+				defObj.TableName = obj.TableName
+			}
+		}
+	}
+	for _, sub := range obj.SubRelations {
+		processNaming(sub, naming, true)
 	}
 }
 
@@ -95,8 +268,12 @@ func ProcessTree(obj *Object) (err error) {
 
 			// if child is not named, append all fields and definitions and sub relations, then delete it
 			if !isNamedObject(defObj) {
+				for _, field := range defObj.Fields {
+					field.Origin.AddRule("flattened into %s", obj.Name)
+				}
 				obj.Fields = append(obj.Fields, defObj.Fields...)
 				obj.SubRelations = append(obj.SubRelations, defObj.SubRelations...)
+				obj.Indexes = append(obj.Indexes, defObj.Indexes...)
 
 				newDefinitions = append(newDefinitions, defObj.Definitions...)
 			} else {
@@ -111,21 +288,34 @@ func ProcessTree(obj *Object) (err error) {
 	return nil
 }
 
-func GenerateModel(sch *schemas.Schema) (obj *Object, err error) {
+func GenerateModel(sch *schemas.Schema, opts *Options) (obj *Object, err error) {
 	// first check if the schema is an object type
 	if !isObjectType(sch.Type) {
 		return nil, errorst.Wrap(ErrWrongSyntax, "Invalid main schema type: %+v", sch.Type)
 	}
 
-	// second: main schema
-	MainSchema = sch
-
-	// third: generate object
-	return GenerateObject(Context{
-		State{
-			Path: sch.ID + "#",
+	// second: generate object
+	ctx := Context{
+		Options:    opts,
+		Schema:     sch,
+		Names:      NewNameRegistry(),
+		Polymorphs: make(map[string]*Object),
+		Ancestors:  []Ancestor{{Pointer: "#", Path: sch.ID + "#", Schema: sch.SubSchema}},
+		State: State{
+			Path:    sch.ID + "#",
+			Pointer: "#",
 		},
-	}, sch.SubSchema)
+	}
+	obj, err = GenerateObject(ctx, sch.SubSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	// third: report renamed types
+	for _, rename := range ctx.Names.Renames {
+		logrus.Warnf("type name %s at %s collides, renamed to %s", rename.From, rename.Path, rename.To)
+	}
+	return obj, nil
 }
 
 func GenerateObject(ctx Context, sch *schemas.SubSchema) (obj *Object, err error) {
@@ -137,7 +327,7 @@ func GenerateObject(ctx Context, sch *schemas.SubSchema) (obj *Object, err error
 			return GeneratePrimitive(ctx, sch)
 		} else if isArrayType(sch.Type) {
 			return GenerateArray(ctx, sch)
-		} else if sch.Ref != "" {
+		} else if hasRef(sch) {
 			return GenerateRef(ctx, sch)
 		} else {
 			return nil, errorst.Wrap(ErrWrongSyntax, "Invalid schema type: %+v", sch.Type)
@@ -146,11 +336,23 @@ func GenerateObject(ctx Context, sch *schemas.SubSchema) (obj *Object, err error
 	}
 
 	// first: process meta-data
-	if name, err := path2Name(ctx.Path); err != nil {
+	obj.Origin = &Origin{Pointer: ctx.Pointer}
+	if name, err := typeName(ctx, sch, ctx.Path, obj.Origin); err != nil {
 		return nil, errorst.Wrap(err, "failed to get object name at %s", ctx.Path)
 	} else {
 		obj.Name = name
 		obj.Comment = getComment(sch)
+		obj.KeyStrategy = KeyStrategy(getStringHint(sch, HintKeyStrategy))
+		obj.Mixins = getStringsHint(sch, HintMixins)
+	}
+	if obj.KeyStrategy != "" {
+		obj.Origin.AddRule("key strategy %s by %s%s", obj.KeyStrategy, schemas.ExtensionPrefix, HintKeyStrategy)
+	}
+	if len(obj.Mixins) > 0 {
+		obj.Origin.AddRule("mixins %s by %s%s", strings.Join(obj.Mixins, ", "), schemas.ExtensionPrefix, HintMixins)
+	}
+	if obj.Indexes, err = getIndexes(sch); err != nil {
+		return nil, errorst.Wrap(err, "failed to get indexes at %s", ctx.Path)
 	}
 
 	// second: check ref  >>> DELETE for we can not name it
@@ -158,20 +360,45 @@ func GenerateObject(ctx Context, sch *schemas.SubSchema) (obj *Object, err error
 	//
 	//}
 
-	// third: process properties
-	for pName, pSch := range sch.Properties {
-		newCtx := Context{
-			State{
-				Require: isRequired(pName, sch),
-				Path:    ctx.Path + "/" + pName,
-			},
-		}
+	// third: process properties in order
+	pNames := make([]string, 0, len(sch.Properties))
+	for pName := range sch.Properties {
+		pNames = append(pNames, pName)
+	}
+	sort.Strings(pNames)
+	for _, pName := range pNames {
+		pSch := sch.Properties[pName]
+		newCtx := ctx.WithState(State{
+			Require: isRequired(pName, sch),
+			Path:    ctx.Path + "/" + pName,
+			Pointer: ctx.Pointer + "/properties/" + schemas.EscapePointer(pName),
+		})
 
 		// get property object and add 2 definitions
 		pObj, err := GenerateObject(newCtx, pSch)
 		if err != nil {
 			return nil, errorst.Wrap(err, "failed to generate object <%s> at %s", pName, ctx.Path)
 		}
+
+		// recursion refers to the ancestor, which belongs to, see ProcessAssociation
+		if pObj.recursive {
+			field := Field{
+				Name:    ctx.Naming.Fields.Format(pName),
+				Type:    Type{Name: pObj.Name, NilAble: true},
+				Tags:    make(map[string]string),
+				Comment: getComment(pSch),
+				Origin:  pObj.Origin,
+
+				recursive: true,
+				external:  pObj.external,
+			}
+			setFieldJsonTag(&field, pName)
+			if !pObj.external {
+				field.Origin.AddRule("recursive object, belongs to %s", pObj.Name)
+			}
+			obj.Fields = append(obj.Fields, field)
+			continue
+		}
 		obj.Definitions = append(obj.Definitions, pObj)
 
 		// if it's a named object, add it to field
@@ -182,17 +409,26 @@ func GenerateObject(ctx Context, sch *schemas.SubSchema) (obj *Object, err error
 			pTyp.NilAble = isRequired(pName, sch)
 
 			field := Field{
-				Name: BigCamelStyle(pName),
-				Type: pTyp,
-				Tags: make(map[string]string),
+				Name:   ctx.Naming.Fields.Format(pName),
+				Type:   pTyp,
+				Tags:   make(map[string]string),
+				Origin: &Origin{Pointer: newCtx.Pointer},
 			}
 			setFieldJsonTag(&field, pName)
 			setFieldGormTag(&field, true)
+			field.Origin.AddRule("named object embedded into %s", obj.Name)
 
 			obj.Fields = append(obj.Fields, field)
 		}
 
 	}
+
+	// forth: process inheritance
+	if inheritance := getStringHint(sch, HintInheritance); inheritance != "" {
+		if err := generateSubtypes(ctx, sch, obj, inheritance); err != nil {
+			return nil, err
+		}
+	}
 	return
 }
 
@@ -200,7 +436,7 @@ func GeneratePrimitive(ctx Context, sch *schemas.SubSchema) (obj *Object, err er
 	obj = &Object{}
 
 	// first: get primitive type
-	typ, err := getPrimitiveType(sch)
+	typ, err := getPrimitiveType(ctx, sch)
 	if err != nil {
 		return nil, errorst.Wrap(err, "failed to get primitive type at %s", ctx.Path)
 	}
@@ -211,7 +447,8 @@ func GeneratePrimitive(ctx Context, sch *schemas.SubSchema) (obj *Object, err er
 	// second: if enums
 	if sch.Enum != nil && len(sch.Enum) > 0 {
 		// create type alias
-		name, err := path2Name(ctx.Path)
+		origin := &Origin{Pointer: ctx.Pointer}
+		name, err := typeName(ctx, sch, ctx.Path, origin)
 		if err != nil {
 			return nil, errorst.Wrap(err, "failed to get enum name at %s", ctx.Path)
 		}
@@ -221,6 +458,7 @@ func GeneratePrimitive(ctx Context, sch *schemas.SubSchema) (obj *Object, err er
 				Name:   typ.Name,
 				Domain: typ.Domain, // we do not care whether inner type is nilAble
 			},
+			Origin: origin,
 		}
 
 		// create enum and add it to definitions
@@ -241,86 +479,575 @@ func GeneratePrimitive(ctx Context, sch *schemas.SubSchema) (obj *Object, err er
 	pathElems := strings.Split(ctx.Path, "/")
 	fName := pathElems[len(pathElems)-1]
 	field := Field{
-		Name:    BigCamelStyle(fName),
+		Name:    ctx.Naming.Fields.Format(fName),
 		Type:    typ,
 		Comment: getComment(sch),
 		Tags:    make(map[string]string),
+		Origin:  &Origin{Pointer: ctx.Pointer},
 	}
 	setFieldJsonTag(&field, fName)
+	if mapped, ok := primitiveMappedType(ctx, sch); ok {
+		field.Origin.AddRule("type mapping to %s", typeString(mapped))
+	}
+	if len(sch.Enum) > 0 {
+		field.Origin.AddRule("enum %s", typ.Name)
+	}
+	if getBoolHint(sch, HintPrimaryKey) {
+		// natural key
+		field.Type.NilAble = false
+		addFieldGormTag(&field, "primaryKey")
+		field.Origin.AddRule("natural primary key by %s%s", schemas.ExtensionPrefix, HintPrimaryKey)
+	}
+	if field.Type.NilAble {
+		field.Origin.AddRule("optional, pointer")
+	}
+	indexes := getPropertyIndexes(sch, field.Name)
+	for _, index := range indexes {
+		if index.Unique {
+			field.Origin.AddRule("unique index by %s%s", schemas.ExtensionPrefix, HintUnique)
+		} else {
+			field.Origin.AddRule("index by %s%s", schemas.ExtensionPrefix, HintIndex)
+		}
+	}
 	obj.Fields = append(obj.Fields, field)
+	obj.Indexes = append(obj.Indexes, indexes...)
 	return
 }
 
 func GenerateArray(ctx Context, sch *schemas.SubSchema) (obj *Object, err error) {
+	if err := checkArrayItems(sch, ctx.Path); err != nil {
+		return nil, err
+	}
+	items, err := derefSchema(ctx, sch.Items)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to get array item at %s", ctx.Path)
+	}
+
+	// array of objects is a sub relation
+	if isObjectType(items.Type) {
+		return generateRelationArray(ctx, sch)
+	}
+
+	// others are decided by storage hint
+	switch storage := getArrayStorage(sch); storage {
+	case ArrayStorageJSON:
+		return generateJSONArray(ctx, sch)
+	case ArrayStorageTable:
+		return generateTableArray(ctx, sch)
+	default:
+		return nil, errorst.Wrap(ErrWrongSyntax, "invalid array storage <%s> at %s", storage, ctx.Path)
+	}
+}
+
+func generateRelationArray(ctx Context, sch *schemas.SubSchema) (obj *Object, err error) {
 	obj = &Object{}
 
-	// get array item type
-	newCtx := Context{
-		State{
-			Path: ctx.Path + "/item",
-		},
+	// polymorphic child by $ref is shared by parents
+	polymorphic := getStringHint(sch, HintPolymorphic)
+	if shared, ok := ctx.Polymorphs[sch.Items.Ref]; ok && polymorphic != "" {
+		field := relationArrayField(ctx, sch, shared)
+		addFieldGormTag(&field, "polymorphic:"+polymorphic)
+		field.Origin.AddRule("polymorphic %s, shares table %s of %s", polymorphic, shared.Name, sch.Items.Ref)
+		obj.Fields = append(obj.Fields, field)
+		return
 	}
+
+	// get array item type
+	newCtx := ctx.WithState(State{
+		Path:    ctx.Path + "/item",
+		Pointer: ctx.Pointer + "/items",
+	})
 	itemObj, err := GenerateObject(newCtx, sch.Items)
 	if err != nil {
 		return nil, errorst.Wrap(err, "failed to generate array item at %s", ctx.Path)
 	}
 
+	// recursion refers to the ancestor, which has many, see ProcessAssociation
+	if itemObj.recursive {
+		field := relationArrayField(ctx, sch, itemObj)
+		field.Origin.Rules = append(field.Origin.Rules, itemObj.Origin.Rules...)
+		if !itemObj.external {
+			field.Origin.AddRule("array of recursive objects, has many %s", itemObj.Name)
+		}
+		field.recursive = true
+		field.external = itemObj.external
+		obj.Fields = append(obj.Fields, field)
+		return
+	}
+
 	// add 2 sub relations
 	obj.SubRelations = append(obj.SubRelations, itemObj)
+	itemObj.Origin.AddRule("array of objects, sub relation")
 
 	// add reference field
+	field := relationArrayField(ctx, sch, itemObj)
+	field.Origin.AddRule("array of objects, has many %s", itemObj.Name)
+	if polymorphic != "" {
+		itemObj.Polymorphic = polymorphic
+		addFieldGormTag(&field, "polymorphic:"+polymorphic)
+		field.Origin.AddRule("polymorphic %s by %s%s", polymorphic, schemas.ExtensionPrefix, HintPolymorphic)
+		if sch.Items.Ref != "" {
+			ctx.Polymorphs[sch.Items.Ref] = itemObj
+		}
+	}
+	obj.Fields = append(obj.Fields, field)
+	return
+}
+
+// checkArrayItems makes sure array sch at path has one items schema. Tuple
+// arrays by prefixItems, or by array items of older drafts, are unsupported
+// as a Go slice has one element type.
+func checkArrayItems(sch *schemas.SubSchema, path string) error {
+	if len(sch.PrefixItems) > 0 {
+		return errorst.Wrap(ErrWrongSyntax, "tuple arrays are unsupported, array at %s has prefixItems (or array form items), use items of one schema", path)
+	}
+	if sch.Items == nil {
+		return errorst.Wrap(ErrWrongSyntax, "array without items at %s", path)
+	}
+	return nil
+}
+
+func generateJSONArray(ctx Context, sch *schemas.SubSchema) (obj *Object, err error) {
+	obj = &Object{}
+
+	// first: find the innermost item and count dimensions
+	var (
+		raw     = sch // item before dereference, to keep $defs name
+		item    = sch
+		path    = ctx.Path
+		pointer = ctx.Pointer
+		dims    = 0
+		arrays  = make(map[*schemas.SubSchema]bool) // arrays seen, which recursive $ref may repeat
+	)
+	for isArrayType(item.Type) {
+		if err := checkArrayItems(item, path); err != nil {
+			return nil, err
+		}
+		if arrays[item] {
+			return nil, errorst.Wrap(ErrWrongSyntax, "recursive $ref to array at %s, arrays of arrays must end in items of other types", path)
+		}
+		arrays[item] = true
+		raw = item.Items
+		if item, err = derefSchema(ctx, raw); err != nil {
+			return nil, errorst.Wrap(err, "failed to get array item at %s", path)
+		}
+		path += "/item"
+		pointer += "/items"
+		dims++
+	}
+
+	// second: get item type
+	newCtx := ctx.WithState(State{
+		Require: true,
+		Path:    path,
+		Pointer: pointer,
+	})
+	itemObj, err := GenerateObject(newCtx, raw)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to generate array item at %s", ctx.Path)
+	}
+	var typ Type
+	if isNamedObject(itemObj) {
+		// object is declared, but stored in json
+		typ = Type{Name: itemObj.Name}
+		if !itemObj.recursive {
+			obj.Definitions = append(obj.Definitions, itemObj)
+		}
+	} else {
+		typ = itemObj.Fields[0].Type
+		obj.Definitions = append(obj.Definitions, itemObj.Definitions...)
+	}
+	typ.NilAble = false
+	typ.IsArray = true
+	typ.Dims = dims
+
+	// third: add json column field
+	pathElems := strings.Split(ctx.Path, "/")
+	fName := pathElems[len(pathElems)-1]
+	field := Field{
+		Name:    ctx.Naming.Fields.Format(fName),
+		Type:    typ,
+		Comment: getComment(sch),
+		Tags:    make(map[string]string),
+	}
+	setFieldJsonTag(&field, fName)
+	field.Tags["gorm"] = "serializer:json"
+	field.Origin = &Origin{Pointer: ctx.Pointer}
+	field.Origin.AddRule("array of %s stored in json %s", typ.Name, arrayStorageRule(sch))
+	obj.Fields = append(obj.Fields, field)
+	return
+}
+
+func generateTableArray(ctx Context, sch *schemas.SubSchema) (obj *Object, err error) {
+	obj = &Object{}
+
+	items, err := derefSchema(ctx, sch.Items)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to get array item at %s", ctx.Path)
+	}
+	newCtx := ctx.WithState(State{
+		Path:    ctx.Path + "/item",
+		Pointer: ctx.Pointer + "/items",
+	})
+
+	// first: each item is a row of child table
+	var row *Object
+	if isObjectType(items.Type) {
+		if row, err = GenerateObject(newCtx, sch.Items); err != nil {
+			return nil, errorst.Wrap(err, "failed to generate array item at %s", ctx.Path)
+		}
+		if row.external {
+			return nil, errorst.Wrap(ErrWrongSyntax, "$ref to %s declared by another schema in array stored in table at %s, use the default storage", row.Name, ctx.Path)
+		}
+		if row.recursive {
+			return nil, errorst.Wrap(ErrWrongSyntax, "recursive $ref in array stored in table at %s, use the default storage", ctx.Path)
+		}
+	} else {
+		row = &Object{Comment: getComment(items), Origin: &Origin{Pointer: newCtx.Pointer}}
+		if row.Name, err = typeName(ctx, items, newCtx.Path, row.Origin); err != nil {
+			return nil, errorst.Wrap(err, "failed to get array item name at %s", ctx.Path)
+		}
+
+		// nested array goes deeper, primitive is stored in value column
+		var valueObj *Object
+		if isArrayType(items.Type) {
+			valueObj, err = generateTableArray(newCtx, items)
+		} else {
+			valueObj, err = GenerateObject(ctx.WithState(State{
+				Require: true,
+				Path:    newCtx.Path + "/value",
+				Pointer: newCtx.Pointer,
+			}), items)
+		}
+		if err != nil {
+			return nil, errorst.Wrap(err, "failed to generate array item at %s", ctx.Path)
+		}
+		for _, field := range valueObj.Fields {
+			field.Origin.AddRule("merged into row %s", row.Name)
+		}
+		row.Fields = append(row.Fields, valueObj.Fields...)
+		row.Definitions = append(row.Definitions, valueObj.Definitions...)
+		row.SubRelations = append(row.SubRelations, valueObj.SubRelations...)
+		row.Indexes = append(row.Indexes, valueObj.Indexes...)
+	}
+
+	// second: keep the order of items
+	row.Fields = append([]Field{ordinalField()}, row.Fields...)
+	obj.SubRelations = append(obj.SubRelations, row)
+	row.Origin.AddRule("array stored in table %s, sub relation", tableStorageRule(sch))
+
+	// third: add reference field
+	field := relationArrayField(ctx, sch, row)
+	field.Origin.AddRule("array stored in table %s, has many %s", tableStorageRule(sch), row.Name)
+	obj.Fields = append(obj.Fields, field)
+	return
+}
+
+func relationArrayField(ctx Context, sch *schemas.SubSchema, itemObj *Object) Field {
 	pathElems := strings.Split(ctx.Path, "/")
 	fName := pathElems[len(pathElems)-1]
 	field := Field{
-		Name: BigCamelStyle(fName) + "Items",
+		Name: ctx.Naming.Fields.Format(fName + "_items"),
 		Type: Type{
 			Name:    itemObj.Name,
 			IsArray: true,
 		},
 		Comment: getComment(sch),
 		Tags:    make(map[string]string),
+		Origin:  &Origin{Pointer: ctx.Pointer},
 	}
 	setFieldJsonTag(&field, fName)
-	obj.Fields = append(obj.Fields, field)
-	return
+	return field
+}
+
+func ordinalField() Field {
+	return Field{
+		Name: "Ordinal",
+		Type: Type{
+			Name: "int",
+		},
+		Tags: map[string]string{
+			"json": "-",
+		},
+		Comment: "position in parent array",
+		Origin:  &Origin{Rules: []string{"ordinal of array items"}},
+	}
 }
 
 func GenerateRef(ctx Context, sch *schemas.SubSchema) (obj *Object, err error) {
 	// first: get ref schema
-	refSch, err := getRefSchema(sch.Ref)
+	pointer, refSch, err := resolveRef(ctx, sch)
 	if err != nil {
 		return nil, errorst.Wrap(err, "failed to get ref schema at %s", ctx.Path)
 	}
-	return GenerateObject(ctx, refSch)
+
+	// second: a ref to an ancestor is recursion, which refers to the ancestor type
+	for _, ancestor := range ctx.Ancestors {
+		if ancestor.Pointer == pointer {
+			return recursiveObject(ctx, ancestor)
+		}
+	}
+
+	// third: remember the definition name for naming
+	ctx.DefName, _ = refDefName(pointer)
+	if getBoolHint(refSch, HintDeclared) && !ctx.Inline {
+		return declaredObject(ctx, pointer, refSch)
+	}
+	from := ctx.Pointer
+	ctx.Pointer = pointer
+	obj, err = GenerateObject(ctx.withAncestor(Ancestor{Pointer: pointer, Path: ctx.Path, Schema: refSch}), refSch)
+	if err != nil {
+		return nil, err
+	}
+	explainRef(obj, from)
+	return obj, nil
+}
+
+// recursiveObject refers to the object generated from ancestor,
+// which is not declared again.
+func recursiveObject(ctx Context, ancestor Ancestor) (*Object, error) {
+	sch, err := derefSchema(ctx, ancestor.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if !isObjectType(sch.Type) {
+		return nil, errorst.Wrap(ErrWrongSyntax, "recursive $ref to non-object %s at %s", ancestor.Pointer, ctx.Path)
+	}
+	name, ok := ctx.Names.Lookup(ancestor.Path)
+	if !ok {
+		return nil, errorst.Wrap(ErrWrongSyntax, "recursive $ref to unnamed object %s at %s", ancestor.Pointer, ctx.Path)
+	}
+	return &Object{
+		Name:      name,
+		Origin:    &Origin{Pointer: ctx.Pointer, Rules: []string{"recursive $ref to " + ancestor.Pointer}},
+		recursive: true,
+	}, nil
+}
+
+// declaredObject refers to the object declared by another schema of
+// the package, which is generated as the root of it, see HintDeclared.
+func declaredObject(ctx Context, pointer string, sch *schemas.SubSchema) (*Object, error) {
+	if !isObjectType(sch.Type) {
+		return nil, errorst.Wrap(ErrWrongSyntax, "%s%s of non-object %s at %s", schemas.ExtensionPrefix, HintDeclared, pointer, ctx.Path)
+	}
+	id := sch.ID
+	if id == "" {
+		id = ctx.DefName
+	}
+
+	// name it as the root of its schema, and keep others from the name
+	rootCtx := ctx.WithState(State{Path: id + "#"})
+	rootCtx.Names = NewNameRegistry()
+	name, err := typeName(rootCtx, sch, rootCtx.Path, &Origin{})
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to get name of %s at %s", pointer, ctx.Path)
+	}
+	if resolved := ctx.Names.Register(rootCtx.Path, name); resolved != name {
+		return nil, errorst.Wrap(ErrWrongSyntax, "name %s of %s declared by another schema is taken at %s", name, pointer, ctx.Path)
+	}
+	return &Object{
+		Name:      name,
+		Origin:    &Origin{Pointer: ctx.Pointer, Rules: []string{"refers to " + name + " by " + schemas.ExtensionPrefix + HintDeclared}},
+		recursive: true,
+		external:  true,
+	}, nil
 }
 
 func addValue2Enum(enum *Enum, value ...schemas.Value) {
 	for _, v := range value {
-		enum.Values = append(enum.Values, v)
+		enum.Values = append(enum.Values, enumValue(v))
 	}
 }
 
-func getRefSchema(path string) (*schemas.SubSchema, error) {
-	// first get def name from path
+func getRefSchema(ctx Context, path string) (*schemas.SubSchema, error) {
+	_, sch, err := resolvePointer(ctx, path)
+	return sch, err
+}
+
+// resolvePointer resolves ref in the main schema to a JSON Pointer of the schema,
+// which is the root `#`, `#/$defs/name`, or the schema of a plain name anchor.
+func resolvePointer(ctx Context, ref string) (string, *schemas.SubSchema, error) {
+	uri, err := url.Parse(ref)
+	if err != nil {
+		return "", nil, errorst.Wrap(err, "failed to parse ref path: %s", ref)
+	}
+
+	switch {
+	case uri.Fragment == "" && (uri.Path == "" || uri.Path == ctx.Schema.ID):
+		return "#", ctx.Schema.SubSchema, nil
+	case strings.HasPrefix(uri.Fragment, "/"):
+		defName, err := refDefName(ref)
+		if err != nil {
+			return "", nil, err
+		}
+		if sch, ok := ctx.Schema.Definitions[defName]; ok {
+			return "#/$defs/" + schemas.EscapePointer(defName), sch, nil
+		}
+	case uri.Fragment != "":
+		// plain name fragment of $anchor or $dynamicAnchor
+		if isAnchor(ctx.Schema.SubSchema, uri.Fragment) {
+			return "#", ctx.Schema.SubSchema, nil
+		}
+		names := make([]string, 0, len(ctx.Schema.Definitions))
+		for name := range ctx.Schema.Definitions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if isAnchor(ctx.Schema.Definitions[name], uri.Fragment) {
+				return "#/$defs/" + schemas.EscapePointer(name), ctx.Schema.Definitions[name], nil
+			}
+		}
+	}
+	return "", nil, errorst.Wrap(ErrWrongSyntax, "failed to get ref schema: %s", ref)
+}
+
+// resolveRef resolves $ref, $dynamicRef or $recursiveRef of sch.
+// Dynamic references resolve to the outermost ancestor with the
+// same dynamic anchor, if the statically resolved schema has one.
+func resolveRef(ctx Context, sch *schemas.SubSchema) (string, *schemas.SubSchema, error) {
+	switch {
+	case sch.DynamicRef != "":
+		pointer, target, err := resolvePointer(ctx, sch.DynamicRef)
+		if err != nil {
+			return "", nil, err
+		}
+		anchor := sch.DynamicRef[strings.Index(sch.DynamicRef, "#")+1:]
+		if anchor != "" && target.DynamicAnchor == anchor {
+			for _, ancestor := range ctx.Ancestors {
+				if ancestor.Schema.DynamicAnchor == anchor {
+					return ancestor.Pointer, ancestor.Schema, nil
+				}
+			}
+		}
+		return pointer, target, nil
+	case sch.RecursiveRef != "":
+		pointer, target, err := resolvePointer(ctx, sch.RecursiveRef)
+		if err != nil {
+			return "", nil, err
+		}
+		if target.RecursiveAnchor {
+			for _, ancestor := range ctx.Ancestors {
+				if ancestor.Schema.RecursiveAnchor {
+					return ancestor.Pointer, ancestor.Schema, nil
+				}
+			}
+		}
+		return pointer, target, nil
+	default:
+		return resolvePointer(ctx, sch.Ref)
+	}
+}
+
+func refDefName(path string) (string, error) {
 	uri, err := url.Parse(path)
 	if err != nil {
-		return nil, errorst.Wrap(err, "failed to parse ref path: %s", path)
+		return "", errorst.Wrap(err, "failed to parse ref path: %s", path)
 	}
 	frags := strings.Split(uri.Fragment, "/")
 	if len(frags) < 3 || frags[1] != "$defs" {
-		return nil, errorst.Wrap(ErrWrongSyntax, "invalid ref path: %s", path)
+		return "", errorst.Wrap(ErrWrongSyntax, "invalid ref path: %s", path)
 	}
-	defName := frags[2]
+	return frags[2], nil
+}
 
-	// second get schema from main schema
-	if sch, ok := MainSchema.Definitions[defName]; ok {
-		return sch, nil
-	} else {
-		return nil, errorst.Wrap(ErrWrongSyntax, "failed to get ref schema: %s", path)
+// derefSchema follows references until a schema without them is reached.
+func derefSchema(ctx Context, sch *schemas.SubSchema) (*schemas.SubSchema, error) {
+	seen := make(map[string]bool)
+	for hasRef(sch) {
+		pointer, refSch, err := resolveRef(ctx, sch)
+		if err != nil {
+			return nil, err
+		}
+		if seen[pointer] {
+			return nil, errorst.Wrap(ErrWrongSyntax, "circular $ref to %s", pointer)
+		}
+		seen[pointer] = true
+		sch = refSch
+	}
+	return sch, nil
+}
+
+// hasRef reports whether sch is a $ref, $dynamicRef or $recursiveRef.
+func hasRef(sch *schemas.SubSchema) bool {
+	return sch.Ref != "" || sch.DynamicRef != "" || sch.RecursiveRef != ""
+}
+
+// isAnchor reports whether sch declares the plain name anchor.
+func isAnchor(sch *schemas.SubSchema, anchor string) bool {
+	return sch.Anchor == anchor || sch.DynamicAnchor == anchor
+}
+
+// typeName names the type at path, collisions are resolved by name registry.
+// Naming rules are added to origin.
+func typeName(ctx Context, sch *schemas.SubSchema, path string, origin *Origin) (string, error) {
+	fullName, err := path2Name(ctx.Naming.Types, path)
+	if err != nil {
+		return "", err
 	}
+
+	var (
+		name         string
+		alternatives []string
+		rule         string
+	)
+	switch ctx.NamePolicy {
+	case NamePolicyShort:
+		// prefer title, $defs key and property name, then full path
+		switch {
+		case sch.Title != "":
+			name = ctx.Naming.Types.Format(sch.Title)
+			rule = "named by title"
+		case ctx.DefName != "":
+			name = ctx.Naming.Types.Format(ctx.DefName)
+			rule = "named by $defs key"
+		default:
+			name = ctx.Naming.Types.Format(shortPathName(path))
+			rule = "named by property"
+		}
+		alternatives = append(alternatives, fullName)
+	default:
+		name = fullName
+		rule = "named by path"
+		if sch.Title != "" {
+			alternatives = append(alternatives, ctx.Naming.Types.Format(sch.Title))
+		}
+	}
+	if goName := getStringHint(sch, HintGoName); goName != "" {
+		name = goName
+		rule = "named by " + schemas.ExtensionPrefix + HintGoName
+	}
+
+	resolved := ctx.Names.Register(path, name, alternatives...)
+	origin.AddRule("%s, name policy %s", rule, ctx.NamePolicy)
+	if resolved != name {
+		origin.AddRule("renamed from %s on collision", name)
+	}
+	return resolved, nil
 }
 
-func path2Name(path string) (string, error) {
+// shortPathName returns the last property name in path,
+// followed by array item elements, or the schema name of root.
+func shortPathName(path string) string {
+	uri, err := url.Parse(path)
+	if err != nil || uri.Fragment == "" {
+		return strings.Split(pathBase(path), ".")[0]
+	}
+
+	frags := strings.Split(uri.Fragment, "/")
+	i := len(frags) - 1
+	for i > 1 && (frags[i] == "item" || frags[i] == "value") {
+		i--
+	}
+	return strings.Join(frags[i:], "_")
+}
+
+func pathBase(path string) string {
+	path = strings.Split(path, "#")[0]
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+func path2Name(style NameStyle, path string) (string, error) {
 	uri, err := url.Parse(path)
 	if err != nil {
 		return "", errorst.Wrap(err, "failed to parse path: %s", path)
@@ -337,11 +1064,7 @@ func path2Name(path string) (string, error) {
 	frags := strings.Split(uri.Fragment, "/")
 
 	// format
-	var ret = BigCamelStyle(schema)
-	for _, f := range frags {
-		ret += BigCamelStyle(f)
-	}
-	return ret, nil
+	return style.Format(strings.Join(append([]string{schema}, frags...), "_")), nil
 }
 
 func setFieldJsonTag(field *Field, name string) {
@@ -357,6 +1080,40 @@ func setFieldGormTag(field *Field, isEmbedded bool) {
 	}
 }
 
+// addFieldGormTag appends a setting to field's gorm tag.
+func addFieldGormTag(field *Field, setting string) {
+	if field.Tags == nil {
+		field.Tags = make(map[string]string)
+	}
+	if field.Tags["gorm"] == "" {
+		field.Tags["gorm"] = setting
+	} else {
+		field.Tags["gorm"] += ";" + setting
+	}
+}
+
+// isColumnField reports whether field is stored in a column,
+// embedded structs and relations are not.
+func isColumnField(field *Field) bool {
+	if field.Name == "" {
+		return false
+	}
+	gormTag := field.Tags["gorm"]
+	if strings.Contains(gormTag, "embedded") || strings.Contains(gormTag, "foreignKey:") && !strings.Contains(gormTag, "serializer") {
+		return false
+	}
+	return !field.Type.IsArray || strings.Contains(gormTag, "serializer")
+}
+
+func fieldIndex(obj *Object, name string) int {
+	for i, field := range obj.Fields {
+		if field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 func getComment(sch *schemas.SubSchema) string {
 	if sch.Title != "" && sch.Description != "" {
 		return sch.Title + ": " + sch.Description
@@ -399,8 +1156,10 @@ func isNilAble(typ schemas.Type) bool {
 	return typ.Contains(schemas.TypeNameNull)
 }
 
-func getPrimitiveType(schema *schemas.SubSchema) (ret Type, err error) {
-	if schema.Type.Contains(schemas.TypeNameString) {
+func getPrimitiveType(ctx Context, schema *schemas.SubSchema) (ret Type, err error) {
+	if typ, ok := primitiveMappedType(ctx, schema); ok {
+		ret = typ
+	} else if schema.Type.Contains(schemas.TypeNameString) {
 		if schema.Format == "date-time" || schema.Format == "date" {
 			ret = Type{
 				Name:   "Time",
@@ -437,3 +1196,13 @@ func getPrimitiveType(schema *schemas.SubSchema) (ret Type, err error) {
 
 	return
 }
+
+// primitiveMappedType looks up the first primitive type of schema in type mapping.
+func primitiveMappedType(ctx Context, schema *schemas.SubSchema) (Type, bool) {
+	for _, name := range []schemas.SchemaNodeType{schemas.TypeNameString, schemas.TypeNameInteger, schemas.TypeNameNumber, schemas.TypeNameBoolean} {
+		if schema.Type.Contains(name) {
+			return ctx.mappedType(string(name), schema.Format)
+		}
+	}
+	return Type{}, false
+}
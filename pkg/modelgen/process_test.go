@@ -0,0 +1,360 @@
+package modelgen
+
+import (
+	"dbgen/pkg/schemas"
+	"strings"
+	"testing"
+)
+
+func mustSchema(t *testing.T, data string) *schemas.Schema {
+	t.Helper()
+	sch, err := schemas.FromJSON(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("FromJSON: %v", err)
+	}
+	return sch
+}
+
+// fieldTypes returns Go types and gorm tags of fields in the tree of obj,
+// by `Object.Field`, like `[]string serializer:json` or `time.Time`.
+func fieldTypes(obj *Object, types map[string]string) map[string]string {
+	if types == nil {
+		types = make(map[string]string)
+	}
+	for _, field := range obj.Fields {
+		typ := field.Type.Name
+		if field.Type.Domain != "" {
+			typ = field.Type.Domain + "." + typ
+		}
+		if field.Type.NilAble {
+			typ = "*" + typ
+		}
+		if field.Type.IsArray {
+			typ = strings.Repeat("[]", max(field.Type.Dims, 1)) + typ
+		}
+		types[obj.Name+"."+field.Name] = strings.TrimSpace(typ + " " + field.Tags["gorm"])
+	}
+	for _, def := range obj.Definitions {
+		if defObj, ok := def.(*Object); ok {
+			fieldTypes(defObj, types)
+		}
+	}
+	for _, sub := range obj.SubRelations {
+		fieldTypes(sub, types)
+	}
+	return types
+}
+
+func TestGenerateNestedArrays(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   map[string]string // types and gorm tags of fields
+	}{
+		{
+			name:   "json column",
+			schema: `{"$id": "Grid", "type": "object", "properties": {"cells": {"type": "array", "items": {"type": "array", "items": {"type": "integer"}}}}}`,
+			want: map[string]string{
+				"Grid.Cells": "[][]int serializer:json",
+			},
+		},
+		{
+			name:   "json column of objects",
+			schema: `{"$id": "Grid", "type": "object", "properties": {"cells": {"type": "array", "items": {"type": "array", "items": {"type": "object", "properties": {"v": {"type": "string"}}}}}}}`,
+			want: map[string]string{
+				"Grid.Cells":          "[][]GridCellsItemItem serializer:json",
+				"GridCellsItemItem.V": "string",
+			},
+		},
+		{
+			name:   "ordinal child table",
+			schema: `{"$id": "Grid", "type": "object", "properties": {"tags": {"type": "array", "items": {"type": "string"}, "x-array-storage": "table"}}}`,
+			want: map[string]string{
+				"Grid.TagsItems":       "[]GridTagsItem",
+				"GridTagsItem.Ordinal": "int",
+				"GridTagsItem.Value":   "string",
+				"GridTagsItem.GridID":  "uint",
+			},
+		},
+		{
+			name:   "nested ordinal child tables",
+			schema: `{"$id": "Grid", "type": "object", "properties": {"rows": {"type": "array", "items": {"type": "array", "items": {"type": "number"}}, "x-array-storage": "table"}}}`,
+			want: map[string]string{
+				"Grid.RowsItems":                  "[]GridRowsItem",
+				"GridRowsItem.Ordinal":            "int",
+				"GridRowsItem.ItemItems":          "[]GridRowsItemItem",
+				"GridRowsItemItem.Ordinal":        "int",
+				"GridRowsItemItem.Value":          "float64",
+				"GridRowsItemItem.GridRowsItemID": "uint",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := GenAndProcess(mustSchema(t, tt.schema), nil)
+			if err != nil {
+				t.Fatalf("GenAndProcess() = %v", err)
+			}
+			types := fieldTypes(obj, nil)
+			for field, want := range tt.want {
+				if got, ok := types[field]; !ok {
+					t.Errorf("no field %s in %v", field, types)
+				} else if got != want {
+					t.Errorf("%s = %q, want %q", field, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateArrayItems(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		err    string // substring of error, empty if generated
+	}{
+		{
+			name:   "items",
+			schema: `{"$id": "Order", "type": "object", "properties": {"tags": {"type": "array", "items": {"type": "string"}}}}`,
+		},
+		{
+			name:   "nested items",
+			schema: `{"$id": "Order", "type": "object", "properties": {"grid": {"type": "array", "items": {"type": "array", "items": {"type": "integer"}}}}}`,
+		},
+		{
+			name:   "without items",
+			schema: `{"$id": "Order", "type": "object", "properties": {"tags": {"type": "array"}}}`,
+			err:    "array without items at Order#/tags",
+		},
+		{
+			name:   "prefixItems",
+			schema: `{"$id": "Order", "type": "object", "properties": {"point": {"type": "array", "prefixItems": [{"type": "number"}, {"type": "number"}]}}}`,
+			err:    "tuple arrays are unsupported, array at Order#/point",
+		},
+		{
+			name:   "draft-07 array items",
+			schema: `{"$schema": "http://json-schema.org/draft-07/schema#", "$id": "Order", "type": "object", "properties": {"point": {"type": "array", "items": [{"type": "number"}], "additionalItems": {"type": "number"}}}}`,
+			err:    "tuple arrays are unsupported, array at Order#/point",
+		},
+		{
+			name:   "nested tuple",
+			schema: `{"$id": "Order", "type": "object", "properties": {"points": {"type": "array", "items": {"type": "array", "prefixItems": [{"type": "number"}]}}}}`,
+			err:    "tuple arrays are unsupported, array at Order#/points/item",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGenerator("model", nil).Generate(mustSchema(t, tt.schema))
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Generate() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Generate() = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
+
+// fieldTags returns gorm tags of fields of obj and its descendants by "Type.Field".
+func fieldTags(obj *Object, tags map[string]string) map[string]string {
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	for _, field := range obj.Fields {
+		tags[obj.Name+"."+field.Name] = field.Tags["gorm"]
+	}
+	for _, def := range obj.Definitions {
+		if defObj, ok := def.(*Object); ok {
+			fieldTags(defObj, tags)
+		}
+	}
+	for _, sub := range obj.SubRelations {
+		fieldTags(sub, tags)
+	}
+	return tags
+}
+
+func TestRecursion(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   map[string]string // gorm tags of fields
+		absent []string          // fields not generated
+		err    string            // substring of error
+	}{
+		{
+			name: "self tree",
+			schema: `{"$id": "Category", "type": "object", "properties": {
+				"name": {"type": "string"},
+				"children": {"type": "array", "items": {"$ref": "#"}},
+				"parent": {"$ref": "#"}
+			}}`,
+			want: map[string]string{
+				"Category.ChildrenItems": "foreignKey:ParentID;references:ID",
+				"Category.Parent":        "foreignKey:ParentID;references:ID",
+				"Category.ParentID":      "",
+			},
+		},
+		{
+			name: "recursiveRef",
+			schema: `{"$schema": "https://json-schema.org/draft/2019-09/schema", "$id": "Tree", "$recursiveAnchor": true,
+				"type": "object", "properties": {"nodes": {"type": "array", "items": {"$recursiveRef": "#"}}}}`,
+			want: map[string]string{
+				"Tree.NodesItems": "foreignKey:ParentID;references:ID",
+				"Tree.ParentID":   "",
+			},
+		},
+		{
+			name: "sub relation to root and dynamicRef",
+			schema: `{"$id": "Menu", "type": "object",
+				"properties": {"items": {"type": "array", "items": {"$ref": "#/$defs/item"}}},
+				"$defs": {"item": {"$dynamicAnchor": "node", "type": "object", "properties": {
+					"subitems": {"type": "array", "items": {"$dynamicRef": "#node"}},
+					"menus": {"type": "array", "items": {"$ref": "#"}},
+					"menu": {"$ref": "#"}
+				}}}}`,
+			want: map[string]string{
+				"MenuItemsItem.SubitemsItems": "foreignKey:ParentID;references:ID",
+				"MenuItemsItem.ParentID":      "",
+				"MenuItemsItem.MenusItems":    "foreignKey:MenuItemsItemID;references:ID",
+				"Menu.MenuItemsItemID":        "",
+				"MenuItemsItem.Menu":          "foreignKey:MenuMenuID;references:ID",
+				"MenuItemsItem.MenuMenuID":    "",
+			},
+			absent: []string{"Menu.ParentID"},
+		},
+		{
+			name: "through a struct which is not a table",
+			schema: `{"$id": "Pet", "type": "object", "properties": {
+				"info": {"type": "object", "properties": {
+					"friends": {"type": "array", "items": {"$ref": "#"}},
+					"best": {"$ref": "#"}
+				}}
+			}}`,
+			want: map[string]string{
+				"PetInfo.FriendsItems": "serializer:json",
+				"PetInfo.Best":         "serializer:json",
+			},
+			absent: []string{"Pet.ParentID", "PetInfo.BestID"},
+		},
+		{
+			name: "to a struct which is not a table",
+			schema: `{"$id": "Doc", "type": "object", "properties": {"meta": {"$ref": "#/$defs/meta"}},
+				"$defs": {"meta": {"type": "object", "properties": {"next": {"$ref": "#/$defs/meta"}}}}}`,
+			want: map[string]string{
+				"DocMeta.Next": "serializer:json",
+			},
+		},
+		{
+			name: "declared by another schema",
+			schema: `{"$id": "Owner", "type": "object", "properties": {
+				"pets": {"type": "array", "items": {"$ref": "#/$defs/Pet"}},
+				"favorite": {"$ref": "#/$defs/Pet"},
+				"litters": {"type": "array", "items": {"type": "array", "items": {"$ref": "#/$defs/Pet"}}}
+			}, "$defs": {"Pet": {"type": "object", "x-declared": true, "properties": {"owner": {"$ref": "#"}}}}}`,
+			want: map[string]string{
+				"Owner.PetsItems": "serializer:json",
+				"Owner.Favorite":  "serializer:json",
+				"Owner.Litters":   "serializer:json",
+			},
+			absent: []string{"OwnerPetsItem.Owner", "OwnerFavorite.Owner", "Owner.OwnerID"},
+		},
+		{
+			name: "circular refs",
+			schema: `{"$id": "Loop", "type": "object", "properties": {"a": {"$ref": "#/$defs/a"}},
+				"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}}`,
+			err: "circular $ref",
+		},
+		{
+			name: "non-object",
+			schema: `{"$id": "List", "type": "object", "properties": {"values": {"$ref": "#/$defs/values"}},
+				"$defs": {"values": {"type": "array", "items": {"$ref": "#/$defs/values"}}}}`,
+			err: "recursive $ref to array at List#/values",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := NewGenerator("model", nil).Generate(mustSchema(t, tt.schema))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Generate() = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate() = %v", err)
+			}
+
+			tags := fieldTags(obj, nil)
+			for field, want := range tt.want {
+				if got, ok := tags[field]; !ok {
+					t.Errorf("no field %s in %v", field, tags)
+				} else if got != want {
+					t.Errorf("gorm tag of %s = %q, want %q", field, got, want)
+				}
+			}
+			for _, field := range tt.absent {
+				if _, ok := tags[field]; ok {
+					t.Errorf("unexpected field %s", field)
+				}
+			}
+			if _, err := NewGenerator("model", nil).Render(obj); err != nil {
+				t.Errorf("Render() = %v", err)
+			}
+		})
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	const schema = `{"$id": "Order", "type": "object", "properties": {
+		"customer": {"type": "object", "properties": {"name": {"type": "string"}}},
+		"buyer": {"title": "Buyer", "type": "object", "properties": {"name": {"type": "string"}}},
+		"client": {"title": "Buyer", "x-go-name": "Client", "type": "object", "properties": {"name": {"type": "string"}}},
+		"shipping": {"$ref": "#/$defs/address"},
+		"billing": {"type": "object", "properties": {"customer": {"type": "object", "properties": {"vat": {"type": "string"}}}}}
+	}, "$defs": {"address": {"type": "object", "properties": {"city": {"type": "string"}}}}}`
+	tests := []struct {
+		policy NamePolicy
+		want   map[string]string // Go types of fields
+	}{
+		{
+			policy: NamePolicyPath,
+			want: map[string]string{
+				"Order.Customer":        "OrderCustomer",
+				"Order.Buyer":           "OrderBuyer",
+				"Order.Client":          "Client",
+				"Order.Shipping":        "OrderShipping",
+				"OrderBilling.Customer": "OrderBillingCustomer",
+			},
+		},
+		{
+			policy: NamePolicyShort,
+			want: map[string]string{
+				"Order.Customer":   "OrderCustomer", // Customer collides, full path name
+				"Order.Buyer":      "Buyer",
+				"Order.Client":     "Client",
+				"Order.Shipping":   "Address",
+				"Billing.Customer": "Customer",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			obj, err := GenAndProcess(mustSchema(t, schema), &Options{NamePolicy: tt.policy})
+			if err != nil {
+				t.Fatalf("GenAndProcess() = %v", err)
+			}
+			types := fieldTypes(obj, nil)
+			for field, want := range tt.want {
+				if got, ok := types[field]; !ok {
+					t.Errorf("no field %s in %v", field, types)
+				} else if got = strings.Fields(got)[0]; got != want {
+					t.Errorf("%s = %q, want %q", field, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package modelgen
+
+import "fmt"
+
+// NameRegistry records type names across a whole generation,
+// so that two types never share the same name.
+type NameRegistry struct {
+	owners  map[string]string // type name -> path owning it
+	names   map[string]string // path -> type name
+	Renames []Rename          // collisions resolved by renaming
+}
+
+// Rename describes a type renamed for collision.
+type Rename struct {
+	Path string // path of the renamed type
+	From string // collided name
+	To   string // resolved name
+}
+
+func NewNameRegistry() *NameRegistry {
+	return &NameRegistry{
+		owners: make(map[string]string),
+		names:  make(map[string]string),
+	}
+}
+
+// Register registers the type at path with name and returns the resolved name.
+// If name is taken by another path, alternatives are tried in order,
+// then name is suffixed with the smallest free number starting from 2.
+func (r *NameRegistry) Register(path string, name string, alternatives ...string) string {
+	if registered, ok := r.names[path]; ok {
+		return registered
+	}
+
+	resolved := name
+	if r.taken(path, name) {
+		resolved = ""
+		for _, alt := range alternatives {
+			if alt != "" && !r.taken(path, alt) {
+				resolved = alt
+				break
+			}
+		}
+		for i := 2; resolved == ""; i++ {
+			if suffixed := fmt.Sprintf("%s%d", name, i); !r.taken(path, suffixed) {
+				resolved = suffixed
+			}
+		}
+		r.Renames = append(r.Renames, Rename{Path: path, From: name, To: resolved})
+	}
+
+	r.owners[resolved] = path
+	r.names[path] = resolved
+	return resolved
+}
+
+// Lookup returns the name registered for the type at path.
+func (r *NameRegistry) Lookup(path string) (string, bool) {
+	name, ok := r.names[path]
+	return name, ok
+}
+
+func (r *NameRegistry) taken(path string, name string) bool {
+	owner, ok := r.owners[name]
+	return ok && owner != path
+}
@@ -0,0 +1,85 @@
+package modelgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNameRegistry(t *testing.T) {
+	type register struct {
+		path, name   string
+		alternatives []string
+		want         string
+	}
+	tests := []struct {
+		name      string
+		registers []register
+		renames   []Rename
+	}{
+		{
+			name: "no collision",
+			registers: []register{
+				{"#", "User", nil, "User"},
+				{"#/properties/address", "Address", nil, "Address"},
+			},
+		},
+		{
+			name: "suffixes",
+			registers: []register{
+				{"#/properties/a/properties/item", "Item", nil, "Item"},
+				{"#/properties/b/properties/item", "Item", nil, "Item2"},
+				{"#/properties/c/properties/item", "Item", nil, "Item3"},
+			},
+			renames: []Rename{
+				{Path: "#/properties/b/properties/item", From: "Item", To: "Item2"},
+				{Path: "#/properties/c/properties/item", From: "Item", To: "Item3"},
+			},
+		},
+		{
+			name: "alternatives first",
+			registers: []register{
+				{"#/properties/a/properties/item", "Item", nil, "Item"},
+				{"#/properties/b/properties/item", "Item", []string{"", "BItem"}, "BItem"},
+				{"#/properties/c/properties/item", "Item", []string{"BItem"}, "Item2"},
+			},
+			renames: []Rename{
+				{Path: "#/properties/b/properties/item", From: "Item", To: "BItem"},
+				{Path: "#/properties/c/properties/item", From: "Item", To: "Item2"},
+			},
+		},
+		{
+			name: "suffix taken",
+			registers: []register{
+				{"#/properties/a", "Item2", nil, "Item2"},
+				{"#/properties/b", "Item", nil, "Item"},
+				{"#/properties/c", "Item", nil, "Item3"},
+			},
+			renames: []Rename{
+				{Path: "#/properties/c", From: "Item", To: "Item3"},
+			},
+		},
+		{
+			name: "same path",
+			registers: []register{
+				{"#/properties/a", "Item", nil, "Item"},
+				{"#/properties/a", "Other", nil, "Item"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewNameRegistry()
+			for _, reg := range tt.registers {
+				if got := r.Register(reg.path, reg.name, reg.alternatives...); got != reg.want {
+					t.Errorf("Register(%q, %q) = %q, want %q", reg.path, reg.name, got, reg.want)
+				}
+				if got, ok := r.Lookup(reg.path); !ok || got != reg.want {
+					t.Errorf("Lookup(%q) = %q, %v, want %q", reg.path, got, ok, reg.want)
+				}
+			}
+			if !reflect.DeepEqual(r.Renames, tt.renames) {
+				t.Errorf("Renames = %v, want %v", r.Renames, tt.renames)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package modelgen
+
+import (
+	"bytes"
+	"github.com/thorn-jmh/errorst"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// >>>>>>>>>>>> template backend renders IR with user templates >>>>>>>>>>>>>>>
+
+// TemplateExt is the extension of template files.
+const TemplateExt = ".tmpl"
+
+// TemplateData is the data passed to templates.
+type TemplateData struct {
+	Package string    // package name of output
+	Model   *Object   // root object
+	Objects []*Object // all struct types in declaration order
+	Tables  []*Object // objects stored in their own tables
+	Aliases []*Alias  // type aliases, not including enums
+	Enums   []*Enum   // enums
+}
+
+// NewTemplateData collects declarations of model.
+func NewTemplateData(pkg string, model *Object) *TemplateData {
+	data := &TemplateData{
+		Package: pkg,
+		Model:   model,
+	}
+	data.collect(model, true)
+	return data
+}
+
+func (t *TemplateData) collect(obj *Object, isTable bool) {
+	t.Objects = append(t.Objects, obj)
+	if isTable {
+		t.Tables = append(t.Tables, obj)
+	}
+	for _, def := range obj.Definitions {
+		switch def := def.(type) {
+		case *Object:
+			t.collect(def, false)
+		case *Alias:
+			t.Aliases = append(t.Aliases, def)
+		case *Enum:
+			t.Enums = append(t.Enums, def)
+		}
+	}
+	for _, sub := range obj.SubRelations {
+		t.collect(sub, true)
+	}
+}
+
+// Templates are output templates, each renders one file.
+type Templates struct {
+	files []*template.Template
+	funcs template.FuncMap
+}
+
+// TemplateFile is a file rendered by template.
+type TemplateFile struct {
+	Name    string
+	Content []byte
+}
+
+// LoadTemplates loads all `*.tmpl` files in dir with funcs, see TemplateFuncs.
+// The file name without extension is also a template, rendered to get the
+// output file name.
+func LoadTemplates(dir string, funcs template.FuncMap) (*Templates, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+TemplateExt))
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to list templates in %s", dir)
+	}
+	if len(paths) == 0 {
+		return nil, errorst.Wrap(ErrWrongSyntax, "no %s template in %s", TemplateExt, dir)
+	}
+	sort.Strings(paths)
+
+	t := &Templates{funcs: funcs}
+	for _, p := range paths {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil, errorst.Wrap(err, "failed to read template %s", p)
+		}
+		name := strings.TrimSuffix(filepath.Base(p), TemplateExt)
+		tmpl, err := template.New(name).Funcs(funcs).Parse(string(content))
+		if err != nil {
+			return nil, errorst.Wrap(err, "failed to parse template %s", p)
+		}
+		t.files = append(t.files, tmpl)
+	}
+	return t, nil
+}
+
+// Execute renders all templates with data, Go files are formatted.
+func (t *Templates) Execute(data *TemplateData) ([]TemplateFile, error) {
+	var files []TemplateFile
+	for _, tmpl := range t.files {
+		// first: render file name
+		var name bytes.Buffer
+		nameTmpl, err := template.New("name").Funcs(t.funcs).Parse(tmpl.Name())
+		if err != nil {
+			return nil, errorst.Wrap(err, "failed to parse file name %s", tmpl.Name())
+		}
+		if err := nameTmpl.Execute(&name, data); err != nil {
+			return nil, errorst.Wrap(err, "failed to render file name %s", tmpl.Name())
+		}
+		if err := checkFileName(name.String()); err != nil {
+			return nil, errorst.Wrap(err, "bad file name rendered by template %s", tmpl.Name())
+		}
+
+		// second: render content
+		var content bytes.Buffer
+		if err := tmpl.Execute(&content, data); err != nil {
+			return nil, errorst.Wrap(err, "failed to render template %s", tmpl.Name())
+		}
+		file := TemplateFile{Name: name.String(), Content: content.Bytes()}
+		if strings.HasSuffix(file.Name, ".go") {
+			if file.Content, err = format.Source(file.Content); err != nil {
+				return nil, errorst.Wrap(err, "failed to format %s rendered by template %s", file.Name, tmpl.Name())
+			}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// checkFileName makes sure a rendered file name stays in the output directory,
+// it must be a plain name without path separators or `..`.
+func checkFileName(name string) error {
+	clean := filepath.Clean(name)
+	if name == "" || clean == "." || clean == ".." || strings.ContainsAny(name, `/\`) || clean != filepath.Base(clean) {
+		return errorst.Wrap(ErrWrongSyntax, "file name %q is not a plain file name", name)
+	}
+	return nil
+}
+
+// TemplateFuncs returns helper functions for templates, name styles
+// of which honor initialisms of naming, as the Go backend does.
+func TemplateFuncs(naming Naming) template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, style := range map[string]string{
+		"bigCamel": "big-camel",
+		"camel":    "camel",
+		"snake":    "snake",
+		"kebab":    "kebab",
+	} {
+		ns, _ := NameStyleByName(style, naming.Initialisms...)
+		funcs[name] = ns.Format
+	}
+	for name, f := range templateFuncs {
+		funcs[name] = f
+	}
+	return funcs
+}
+
+// templateFuncs are helper functions of templates, besides name styles.
+var templateFuncs = template.FuncMap{
+	// types
+	"goType":  TypeString,
+	"imports": Imports,
+	// fields and tags
+	"tags":        TagString,
+	"tag":         func(field Field, key string) string { return field.Tags[key] },
+	"isColumn":    func(field Field) bool { return isColumnField(&field) },
+	"columnName":  func(field Field) string { return columnName(&field) },
+	"tableName":   tableName,
+	"primaryKeys": primaryKeys,
+	// strings
+	"join":      strings.Join,
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"hasPrefix": strings.HasPrefix,
+	"hasSuffix": strings.HasSuffix,
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// TypeString renders typ in Go syntax, qualified by package name.
+func TypeString(typ Type) string {
+	var b strings.Builder
+	if typ.IsArray {
+		b.WriteString("[]")
+		for i := 1; i < typ.Dims; i++ {
+			b.WriteString("[]")
+		}
+	} else if typ.NilAble {
+		b.WriteString("*")
+	}
+	if typ.Domain != "" {
+		b.WriteString(packageName(typ.Domain) + ".")
+	}
+	b.WriteString(typ.Name)
+	return b.String()
+}
+
+// packageName guesses package name by its path, e.g. `ulid` for
+// `github.com/oklog/ulid/v2`.
+func packageName(pkgPath string) string {
+	name := path.Base(pkgPath)
+	if majorVersion.MatchString(name) {
+		name = path.Base(path.Dir(pkgPath))
+	}
+	return name
+}
+
+// Imports returns sorted package paths used by fields of objects.
+func Imports(objects []*Object) []string {
+	set := make(map[string]bool)
+	for _, obj := range objects {
+		for _, field := range obj.Fields {
+			if field.Type.Domain != "" {
+				set[field.Type.Domain] = true
+			}
+		}
+	}
+	var imports []string
+	for imp := range set {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// TagString renders tags of field in Go syntax, sorted by key.
+func TagString(field Field) string {
+	keys := make([]string, 0, len(field.Tags))
+	for k := range field.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		pairs = append(pairs, k+`:"`+field.Tags[k]+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "`" + strings.Join(pairs, " ") + "`"
+}
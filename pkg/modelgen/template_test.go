@@ -0,0 +1,81 @@
+package modelgen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"order.go", true},
+		{"order_gen.sql", true},
+		{"..order.go", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../order.go", false},
+		{"sub/order.go", false},
+		{"/tmp/order.go", false},
+		{`sub\order.go`, false},
+		{"./order.go", false},
+	}
+	for _, tt := range tests {
+		err := checkFileName(tt.name)
+		if (err == nil) != tt.ok {
+			t.Errorf("checkFileName(%q) = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestTemplatesRejectEscapingNames(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "{{.Package}}.txt"+TemplateExt)
+	if err := os.WriteFile(file, []byte("{{.Package}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpls, err := LoadTemplates(dir, TemplateFuncs(Naming{}))
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	files, err := tmpls.Execute(&TemplateData{Package: "model"})
+	if err != nil || len(files) != 1 || files[0].Name != "model.txt" {
+		t.Fatalf("Execute = %v, %v, want model.txt", files, err)
+	}
+	if _, err := tmpls.Execute(&TemplateData{Package: "../model"}); err == nil {
+		t.Errorf("Execute with ../model succeeded, want error")
+	}
+}
+
+func TestTemplateFuncsInitialisms(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "names.txt"+TemplateExt)
+	if err := os.WriteFile(file, []byte(`{{bigCamel "order_sku"}} {{camel "sku_code"}} {{snake "OrderSKU"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		naming Naming
+		want   string
+	}{
+		{Naming{}, "OrderSku skuCode order_sku"},
+		{Naming{Initialisms: []string{"sku"}}, "OrderSKU skuCode order_sku"},
+	}
+	for _, tt := range tests {
+		tmpls, err := LoadTemplates(dir, TemplateFuncs(tt.naming))
+		if err != nil {
+			t.Fatalf("LoadTemplates: %v", err)
+		}
+		files, err := tmpls.Execute(&TemplateData{Package: "model"})
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+		if got := string(files[0].Content); got != tt.want {
+			t.Errorf("initialisms %v rendered %q, want %q", tt.naming.Initialisms, got, tt.want)
+		}
+	}
+}
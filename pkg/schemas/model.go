@@ -1,8 +1,10 @@
 package schemas
 
 import (
+	"bytes"
 	"encoding/json"
 	"github.com/thorn-jmh/errorst"
+	"strings"
 )
 
 // Definitions hold schema definitions.
@@ -44,8 +46,15 @@ type SubSchema SchemaProperties
 type SchemaProperties struct {
 	// ID and Reference
 	// https://json-schema.org/draft/2020-12/json-schema-core
-	ID  string `json:"$id"`            // #section-8.2.1
-	Ref string `json:"$ref,omitempty"` // #section-8.2.3
+	ID            string `json:"$id"`                      // #section-8.2.1
+	Anchor        string `json:"$anchor,omitempty"`        // #section-8.2.2
+	DynamicAnchor string `json:"$dynamicAnchor,omitempty"` // #section-8.2.2
+	Ref           string `json:"$ref,omitempty"`           // #section-8.2.3.1
+	DynamicRef    string `json:"$dynamicRef,omitempty"`    // #section-8.2.3.2
+	// Recursive reference of 2019-09
+	// https://json-schema.org/draft/2019-09/json-schema-core#section-8.2.4.2
+	RecursiveRef    string `json:"$recursiveRef,omitempty"`
+	RecursiveAnchor bool   `json:"$recursiveAnchor,omitempty"`
 
 	// Meta-Data
 	// https://json-schema.org/draft/2020-12/json-schema-validation#section-9
@@ -94,6 +103,8 @@ type SchemaProperties struct {
 	AnyOf []*SubSchema `json:"anyOf,omitempty"` // #section-10.2.1.2
 	OneOf []*SubSchema `json:"oneOf,omitempty"` // #section-10.2.1.3
 	Not   *SubSchema   `json:"not,omitempty"`   // #section-10.2.1.4
+	// Conditional
+	DependentSchemas map[string]*SubSchema `json:"dependentSchemas,omitempty"` // #section-10.2.2.4
 	// For array
 	PrefixItems []*SubSchema `json:"prefixItems,omitempty"` // #section-10.3.1.1
 	Items       *SubSchema   `json:"items,omitempty"`       // #section-10.3.1.2
@@ -103,20 +114,52 @@ type SchemaProperties struct {
 	PatternProperties    map[string]*SubSchema `json:"patternProperties,omitempty"`    // #section-10.3.2.2
 	AdditionalProperties *SubSchema            `json:"additionalProperties,omitempty"` // #section-10.3.2.3
 	PropertyNames        *SubSchema            `json:"propertyNames,omitempty"`        // #section-10.3.2.4
+
+	// Extensions
+	// Keywords prefixed with "x-" are not part of the spec, we collect them
+	// here as generator hints.
+	Extensions map[string]Value `json:"-"`
+}
+
+// Extension returns the value of extension keyword `x-<name>`.
+func (s *SubSchema) Extension(name string) (Value, bool) {
+	if s == nil || s.Extensions == nil {
+		return nil, false
+	}
+	v, ok := s.Extensions[ExtensionPrefix+name]
+	return v, ok
 }
 
+// ExtensionPrefix is the prefix of extension keywords.
+const ExtensionPrefix = "x-"
+
 // >>>>>>>>>>>>>>>>>>>> impl UnmarshalJSON >>>>>>>>>>>>>>>>>>>>>>>
 
 // type alias for unmarshal
-type schemaToUnmarshal Schema
+type schemaToUnmarshal struct {
+	Definitions Definitions `json:"$defs,omitempty"`
+	Version     string      `json:"$schema,omitempty"`
+}
 type subSchemaToUnmarshal SchemaProperties
 
 // UnmarshalJSON implements json.Unmarshaler for Schema struct.
 func (s *Schema) UnmarshalJSON(data []byte) error {
-	var unmarshalSchema schemaToUnmarshal
+	// Normalize older drafts into 2020-12 first.
+	data, err := normalizeJSON(data)
+	if err != nil {
+		return err
+	}
 
+	// NOTE: the embedded SubSchema has its own UnmarshalJSON,
+	// so root fields and subSchema fields are unmarshalled separately.
+	var unmarshalSchema schemaToUnmarshal
 	if err := json.Unmarshal(data, &unmarshalSchema); err != nil {
-		return errorst.NewError("failed to unmarshal schema: %w", err)
+		return errorst.Wrap(err, "failed to unmarshal schema")
+	}
+
+	var subSchema SubSchema
+	if err := json.Unmarshal(data, &subSchema); err != nil {
+		return errorst.Wrap(err, "failed to unmarshal schema")
 	}
 
 	// Take care of legacy fields.
@@ -125,7 +168,7 @@ func (s *Schema) UnmarshalJSON(data []byte) error {
 		Definitions Definitions `json:"definitions,omitempty"`
 	}
 	if err := json.Unmarshal(data, &legacySchema); err != nil {
-		return errorst.NewError("failed to unmarshal schema: %w", err)
+		return errorst.Wrap(err, "failed to unmarshal schema")
 	}
 
 	// Fall back to definitions if $defs is not present.
@@ -133,18 +176,50 @@ func (s *Schema) UnmarshalJSON(data []byte) error {
 		unmarshalSchema.Definitions = legacySchema.Definitions
 	}
 
-	*s = (Schema)(unmarshalSchema)
+	*s = Schema{
+		Definitions: unmarshalSchema.Definitions,
+		Version:     unmarshalSchema.Version,
+		SubSchema:   &subSchema,
+	}
 
 	return nil
 }
 
+// normalizeJSON normalizes schema document data by the draft of its $schema.
+func normalizeJSON(data []byte) ([]byte, error) {
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, errorst.Wrap(err, "failed to unmarshal schema")
+	}
+	root, ok := doc.(map[string]any)
+	if !ok {
+		return data, nil
+	}
+	version, _ := root["$schema"].(string)
+	draft, err := DraftOf(version)
+	if err != nil {
+		return nil, err
+	}
+	if draft == Draft2019 || draft == Draft2020 {
+		return data, nil
+	}
+
+	data, err = json.Marshal(Normalize(doc, draft))
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to marshal normalized schema")
+	}
+	return data, nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler for Type.
 func (t *Type) UnmarshalJSON(b []byte) error {
 	// if the type is a list, unmarshal it as a list of strings.
 	if len(b) > 0 && b[0] == '[' {
 		var s []SchemaNodeType
 		if err := json.Unmarshal(b, &s); err != nil {
-			return errorst.NewError("failed to unmarshal type list: %w", err)
+			return errorst.Wrap(err, "failed to unmarshal type list")
 		}
 		*t = s
 		return nil
@@ -153,7 +228,7 @@ func (t *Type) UnmarshalJSON(b []byte) error {
 	// else unmarshal it as a single string.
 	var s SchemaNodeType
 	if err := json.Unmarshal(b, &s); err != nil {
-		return errorst.NewError("failed to unmarshal type: %w", err)
+		return errorst.Wrap(err, "failed to unmarshal type")
 	}
 	if s != "" {
 		*t = []SchemaNodeType{s}
@@ -180,7 +255,7 @@ func (value *SchemaProperties) UnmarshalJSON(raw []byte) error {
 
 	var obj subSchemaToUnmarshal
 	if err := json.Unmarshal(raw, &obj); err != nil {
-		return errorst.NewError("failed to unmarshal subSchema: %w", err)
+		return errorst.Wrap(err, "failed to unmarshal subSchema")
 	}
 
 	// Take care of legacy fields from older RFC versions.
@@ -189,13 +264,32 @@ func (value *SchemaProperties) UnmarshalJSON(raw []byte) error {
 		ID string `json:"id"`
 	}{}
 	if err := json.Unmarshal(raw, &legacySubSchema); err != nil {
-		return errorst.NewError("failed to unmarshal subSchema: %w", err)
+		return errorst.Wrap(err, "failed to unmarshal subSchema")
 	}
 	if obj.ID == "" {
 		obj.ID = legacySubSchema.ID
 	}
 
+	// Collect extension keywords.
+	var keywords map[string]Value
+	if err := json.Unmarshal(raw, &keywords); err != nil {
+		return errorst.Wrap(err, "failed to unmarshal subSchema")
+	}
+	for k, v := range keywords {
+		if strings.HasPrefix(k, ExtensionPrefix) {
+			if obj.Extensions == nil {
+				obj.Extensions = make(map[string]Value)
+			}
+			obj.Extensions[k] = v
+		}
+	}
+
 	*value = SchemaProperties(obj)
 
 	return nil
 }
+
+// UnmarshalJSON implements json.Unmarshaler for SubSchema.
+func (s *SubSchema) UnmarshalJSON(raw []byte) error {
+	return (*SchemaProperties)(s).UnmarshalJSON(raw)
+}
@@ -0,0 +1,171 @@
+package schemas
+
+import (
+	"strings"
+)
+
+// >>>>>>>>>>>> normalize documents of older drafts into the 2020-12 model >>>>>>>>>>>>>>>
+
+// Normalize rewrites schema document doc of draft in place into 2020-12,
+// and returns it. Documents of 2019-09 and 2020-12 are returned as is.
+//
+//   - draft-04 `id` is `$id`, boolean `exclusiveMaximum` and `exclusiveMinimum`
+//     turn `maximum` and `minimum` into exclusive ones
+//   - `definitions` is `$defs`, and $ref to them are rewritten
+//   - array form `items` is `prefixItems`, then `additionalItems` is `items`
+//   - `dependencies` is split into `dependentRequired` and `dependentSchemas`
+//   - `$ref` overrides its siblings, which are dropped
+func Normalize(doc any, draft Draft) any {
+	if draft == Draft2019 || draft == Draft2020 {
+		return doc
+	}
+	normalizeSchema(doc, draft)
+	return doc
+}
+
+func normalizeSchema(v any, draft Draft) {
+	sch, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+
+	// first: $ref overrides siblings, except definitions which may be referenced
+	if ref, ok := sch["$ref"].(string); ok {
+		for k := range sch {
+			if k != "$ref" && k != "definitions" && k != "$defs" {
+				delete(sch, k)
+			}
+		}
+		sch["$ref"] = normalizeRef(ref)
+	}
+
+	// second: rename keywords
+	if draft == Draft4 {
+		renameKeyword(sch, "id", "$id")
+		normalizeExclusive(sch, "exclusiveMaximum", "maximum")
+		normalizeExclusive(sch, "exclusiveMinimum", "minimum")
+	}
+	renameKeyword(sch, "definitions", "$defs")
+	if items, ok := sch["items"].([]any); ok {
+		delete(sch, "items")
+		sch["prefixItems"] = items
+		renameKeyword(sch, "additionalItems", "items")
+	} else {
+		delete(sch, "additionalItems")
+	}
+	if deps, ok := sch["dependencies"].(map[string]any); ok {
+		delete(sch, "dependencies")
+		required, subs := make(map[string]any), make(map[string]any)
+		for name, dep := range deps {
+			if _, ok := dep.([]any); ok {
+				required[name] = dep
+			} else {
+				subs[name] = dep
+			}
+		}
+		if len(required) > 0 {
+			sch["dependentRequired"] = required
+		}
+		if len(subs) > 0 {
+			sch["dependentSchemas"] = subs
+		}
+	}
+
+	// third: subschemas
+	for _, k := range schemaKeywords {
+		normalizeSchema(sch[k], draft)
+	}
+	for _, k := range schemaListKeywords {
+		if subs, ok := sch[k].([]any); ok {
+			for _, sub := range subs {
+				normalizeSchema(sub, draft)
+			}
+		}
+	}
+	for _, k := range schemaMapKeywords {
+		if subs, ok := sch[k].(map[string]any); ok {
+			for _, sub := range subs {
+				normalizeSchema(sub, draft)
+			}
+		}
+	}
+}
+
+func renameKeyword(sch map[string]any, from string, to string) {
+	if v, ok := sch[from]; ok {
+		delete(sch, from)
+		if _, ok := sch[to]; !ok {
+			sch[to] = v
+		}
+	}
+}
+
+// normalizeExclusive turns draft-04 boolean exclusive of limit into
+// the numeric form.
+func normalizeExclusive(sch map[string]any, exclusive string, limit string) {
+	flag, ok := sch[exclusive].(bool)
+	if !ok {
+		return
+	}
+	delete(sch, exclusive)
+	if v, ok := sch[limit]; ok && flag {
+		delete(sch, limit)
+		sch[exclusive] = v
+	}
+}
+
+// normalizeRef rewrites keywords renamed by Normalize in the JSON Pointer
+// of ref, where they are keywords rather than property names.
+func normalizeRef(ref string) string {
+	i := strings.Index(ref, "#/")
+	if i < 0 {
+		return ref
+	}
+	var (
+		segs    = strings.Split(ref[i+2:], "/")
+		keyword = true // whether the segment is a keyword
+	)
+	for j, seg := range segs {
+		if !keyword {
+			keyword = true
+			continue
+		}
+		switch {
+		case seg == "definitions":
+			segs[j] = "$defs"
+		case seg == "additionalItems":
+			segs[j] = "items"
+		case seg == "items" && j+1 < len(segs) && isIndex(segs[j+1]):
+			segs[j] = "prefixItems"
+		}
+		switch {
+		case contains(schemaMapKeywords, seg), contains(schemaListKeywords, seg) && seg != "items":
+			// followed by a name or an index
+			keyword = false
+		case seg == "items" && j+1 < len(segs) && isIndex(segs[j+1]):
+			keyword = false
+		}
+	}
+	return ref[:i+2] + strings.Join(segs, "/")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package schemas
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		draft Draft
+		doc   string
+		want  string
+	}{
+		{
+			name:  "2020-12 as is",
+			draft: Draft2020,
+			doc:   `{"definitions": {"a": {"items": [{"type": "string"}]}}}`,
+			want:  `{"definitions": {"a": {"items": [{"type": "string"}]}}}`,
+		},
+		{
+			name:  "draft-04 id and exclusive limits",
+			draft: Draft4,
+			doc:   `{"id": "order", "maximum": 10, "exclusiveMaximum": true, "minimum": 0, "exclusiveMinimum": false}`,
+			want:  `{"$id": "order", "exclusiveMaximum": 10, "minimum": 0}`,
+		},
+		{
+			name:  "draft-04 exclusive without limit",
+			draft: Draft4,
+			doc:   `{"exclusiveMinimum": true}`,
+			want:  `{}`,
+		},
+		{
+			name:  "draft-07 numeric exclusive limits",
+			draft: Draft7,
+			doc:   `{"exclusiveMaximum": 10, "id": "kept"}`,
+			want:  `{"exclusiveMaximum": 10, "id": "kept"}`,
+		},
+		{
+			name:  "definitions and refs",
+			draft: Draft7,
+			doc:   `{"properties": {"a": {"$ref": "#/definitions/a"}, "b": {"$ref": "other.json#/definitions/properties/definitions"}}, "definitions": {"a": {"type": "string"}}}`,
+			want:  `{"properties": {"a": {"$ref": "#/$defs/a"}, "b": {"$ref": "other.json#/$defs/properties/$defs"}}, "$defs": {"a": {"type": "string"}}}`,
+		},
+		{
+			name:  "property named definitions",
+			draft: Draft7,
+			doc:   `{"properties": {"definitions": {"$ref": "#/properties/definitions/items/0"}}}`,
+			want:  `{"properties": {"definitions": {"$ref": "#/properties/definitions/prefixItems/0"}}}`,
+		},
+		{
+			name:  "tuple items",
+			draft: Draft6,
+			doc:   `{"type": "array", "items": [{"type": "string"}, {"type": "integer"}], "additionalItems": false}`,
+			want:  `{"type": "array", "prefixItems": [{"type": "string"}, {"type": "integer"}], "items": false}`,
+		},
+		{
+			name:  "single items",
+			draft: Draft6,
+			doc:   `{"type": "array", "items": {"type": "string"}, "additionalItems": false}`,
+			want:  `{"type": "array", "items": {"type": "string"}}`,
+		},
+		{
+			name:  "dependencies",
+			draft: Draft7,
+			doc:   `{"dependencies": {"a": ["b"], "c": {"required": ["d"]}}}`,
+			want:  `{"dependentRequired": {"a": ["b"]}, "dependentSchemas": {"c": {"required": ["d"]}}}`,
+		},
+		{
+			name:  "ref siblings",
+			draft: Draft7,
+			doc:   `{"$ref": "#/definitions/a", "type": "string", "definitions": {"a": {"type": "string"}}}`,
+			want:  `{"$ref": "#/$defs/a", "$defs": {"a": {"type": "string"}}}`,
+		},
+		{
+			name:  "nested subschemas",
+			draft: Draft4,
+			doc:   `{"properties": {"a": {"oneOf": [{"id": "x", "items": {"definitions": {}}}]}}}`,
+			want:  `{"properties": {"a": {"oneOf": [{"$id": "x", "items": {"$defs": {}}}]}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc, want any
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if got := Normalize(doc, tt.draft); !reflect.DeepEqual(got, want) {
+				data, _ := json.Marshal(got)
+				t.Errorf("Normalize() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/thorn-jmh/errorst"
+	"gopkg.in/yaml.v3"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// >>>>>>>>>>>> read components.schemas of OpenAPI 3.0 and 3.1 documents as JSON Schemas >>>>>>>>>>>>>>>
+
+var ErrUnsupportedOpenAPI = errorst.Wrap(ErrUnsupportedVersion, "unsupported openapi")
+
+// ComponentsRef is the prefix of $ref to component schemas of OpenAPI.
+const ComponentsRef = "#/components/schemas/"
+
+// generator hints set by OpenAPI discriminator and declared components, see modelgen hints
+const (
+	inheritanceHint        = ExtensionPrefix + "inheritance"
+	discriminatorHint      = ExtensionPrefix + "discriminator"
+	discriminatorValueHint = ExtensionPrefix + "discriminator-value"
+	declaredHint           = ExtensionPrefix + "declared"
+)
+
+// OpenAPI is an OpenAPI 3.0 or 3.1 document in JSON or YAML,
+// of which only components.schemas are read.
+type OpenAPI struct {
+	Version string         // value of `openapi`
+	comps   map[string]any // components.schemas converted to JSON Schema, never changed
+}
+
+// IsOpenAPI reports whether data is an OpenAPI document rather than a schema.
+func IsOpenAPI(data []byte) bool {
+	var doc struct {
+		OpenAPI any `yaml:"openapi"`
+	}
+	return yaml.Unmarshal(data, &doc) == nil && doc.OpenAPI != nil
+}
+
+// ParseOpenAPI parses the OpenAPI document data, only 3.0 and 3.1 are supported.
+// Component schemas are decoded and converted once, schemas of them are copies.
+func ParseOpenAPI(data []byte) (*OpenAPI, error) {
+	var doc struct {
+		OpenAPI    string `yaml:"openapi"`
+		Components struct {
+			Schemas map[string]any `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errorst.Wrap(err, "failed to unmarshal OpenAPI document")
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.0") && !strings.HasPrefix(doc.OpenAPI, "3.1") {
+		return nil, errorst.Wrap(ErrUnsupportedOpenAPI, "unsupported openapi %s, supported versions are 3.0 and 3.1", doc.OpenAPI)
+	}
+
+	comps := doc.Components.Schemas
+	if comps == nil {
+		comps = make(map[string]any)
+	}
+	for _, comp := range comps {
+		if strings.HasPrefix(doc.OpenAPI, "3.0") {
+			// 3.0 schemas are an extended subset of draft-04
+			Normalize(comp, Draft4)
+		}
+		convertOpenAPI(comp)
+	}
+	return &OpenAPI{Version: doc.OpenAPI, comps: comps}, nil
+}
+
+// Components returns names of component schemas in order, which
+// are objects if objects is set, as only objects are models.
+func (o *OpenAPI) Components(objects bool) []string {
+	names := make([]string, 0, len(o.comps))
+	for name, comp := range o.comps {
+		if !objects || isObjectSchema(comp) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Schema returns the JSON Schema document of component name, whose $defs
+// are the other components it refers to, with references to them rewritten.
+// Object components in declared are generated by other schemas of the
+// package, they are marked by `x-declared` to be referred to by type.
+func (o *OpenAPI) Schema(name string, declared ...string) ([]byte, error) {
+	return o.schema(name, true, declared)
+}
+
+// ComponentSchema returns the JSON Schema document of component name
+// alone, references to other components are kept unresolved.
+func (o *OpenAPI) ComponentSchema(name string) ([]byte, error) {
+	return o.schema(name, false, nil)
+}
+
+func (o *OpenAPI) schema(name string, withDefs bool, declared []string) ([]byte, error) {
+	// first: copy the component, as root of the document
+	if _, ok := o.comps[name].(map[string]any); !ok {
+		return nil, errorst.Wrap(ErrInvalidSchema, "component schema %s not found", name)
+	}
+	refs := make(map[string]bool)
+	root := copySchema(o.comps[name], name, refs).(map[string]any)
+	if _, ok := root["$id"]; !ok {
+		root["$id"] = name
+	}
+
+	// second: copy components referred to, directly or not, as definitions
+	if withDefs {
+		defs, _ := root["$defs"].(map[string]any)
+		if defs == nil {
+			defs = make(map[string]any)
+		}
+		for len(refs) > 0 {
+			for compName := range refs {
+				delete(refs, compName)
+				if _, ok := defs[compName]; ok || compName == name {
+					continue
+				}
+				comp, ok := o.comps[compName]
+				if !ok {
+					continue
+				}
+				def := copySchema(comp, name, refs)
+				if slices.Contains(declared, compName) && isObjectSchema(def) {
+					def.(map[string]any)[declaredHint] = true
+				}
+				defs[compName] = def
+			}
+		}
+		if len(defs) > 0 {
+			root["$defs"] = defs
+		}
+	}
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to marshal component schema %s", name)
+	}
+	return data, nil
+}
+
+// FromOpenAPI reads component name of the OpenAPI document data as a Schema,
+// see OpenAPI.Schema for declared.
+func FromOpenAPI(data []byte, name string, declared ...string) (*Schema, error) {
+	doc, err := ParseOpenAPI(data)
+	if err != nil {
+		return nil, err
+	}
+	return doc.FromComponent(name, declared...)
+}
+
+// FromComponent reads component name as a Schema, see Schema for declared.
+func (o *OpenAPI) FromComponent(name string, declared ...string) (*Schema, error) {
+	sch, err := o.Schema(name, declared...)
+	if err != nil {
+		return nil, err
+	}
+	return FromJSON(bytes.NewReader(sch))
+}
+
+// copySchema deep copies the converted schema v, with references to
+// components rewritten by componentRef for component root. Names of
+// components referred to are added to refs.
+func copySchema(v any, root string, refs map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		sch := make(map[string]any, len(v))
+		for k, sub := range v {
+			if ref, ok := sub.(string); ok && k == "$ref" {
+				if comp, ok := refComponent(ref); ok {
+					refs[comp] = true
+				}
+				sch[k] = componentRef(ref, root)
+				continue
+			}
+			sch[k] = copySchema(sub, root, refs)
+		}
+		return sch
+	case []any:
+		list := make([]any, len(v))
+		for i, sub := range v {
+			list[i] = copySchema(sub, root, refs)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// convertOpenAPI rewrites the OpenAPI schema v in place into JSON Schema,
+// `$ref` to components are rewritten when it is copied, see copySchema.
+//
+//   - `nullable` adds null to `type`
+//   - `discriminator` of `oneOf` or `anyOf` is single-table inheritance of an
+//     object base, mapping values are set on subtypes by `x-discriminator-value`
+//   - `example` is `examples`, `xml` and `externalDocs` are dropped
+//   - `x-*` extensions are kept as hints
+func convertOpenAPI(v any) {
+	sch, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+
+	// first: keywords of OpenAPI
+	if nullable, ok := sch["nullable"].(bool); ok {
+		delete(sch, "nullable")
+		switch typ := sch["type"].(type) {
+		case string:
+			if nullable && typ != string(TypeNameNull) {
+				sch["type"] = []any{typ, string(TypeNameNull)}
+			}
+		case []any:
+			if nullable && !containsValue(typ, string(TypeNameNull)) {
+				sch["type"] = append(typ, string(TypeNameNull))
+			}
+		}
+	}
+	if disc, ok := sch["discriminator"].(map[string]any); ok {
+		delete(sch, "discriminator")
+		if _, ok := sch["oneOf"]; !ok {
+			renameKeyword(sch, "anyOf", "oneOf")
+		}
+		if subs, ok := sch["oneOf"].([]any); ok {
+			// the base of subtypes is an object
+			setDefault(sch, "type", string(TypeNameObject))
+			setDefault(sch, inheritanceHint, "single-table")
+			if prop, ok := disc["propertyName"].(string); ok {
+				setDefault(sch, discriminatorHint, prop)
+			}
+			mapping, _ := disc["mapping"].(map[string]any)
+			for value, target := range mapping {
+				target, _ := target.(string)
+				for _, sub := range subs {
+					sub, ok := sub.(map[string]any)
+					if ok && target != "" && (sub["$ref"] == target || sub["$ref"] == ComponentsRef+target) {
+						setDefault(sub, discriminatorValueHint, value)
+					}
+				}
+			}
+		}
+	}
+	if example, ok := sch["example"]; ok {
+		delete(sch, "example")
+		if _, ok := sch["examples"]; !ok {
+			sch["examples"] = []any{example}
+		}
+	}
+	delete(sch, "xml")
+	delete(sch, "externalDocs")
+
+	// second: subschemas
+	for _, k := range schemaKeywords {
+		convertOpenAPI(sch[k])
+	}
+	for _, k := range schemaListKeywords {
+		if subs, ok := sch[k].([]any); ok {
+			for _, sub := range subs {
+				convertOpenAPI(sub)
+			}
+		}
+	}
+	for _, k := range schemaMapKeywords {
+		if subs, ok := sch[k].(map[string]any); ok {
+			for _, sub := range subs {
+				convertOpenAPI(sub)
+			}
+		}
+	}
+}
+
+// componentRef rewrites ref to components into $defs, and ref to
+// component root into the document root.
+func componentRef(ref string, root string) string {
+	if !strings.HasPrefix(ref, ComponentsRef) {
+		return ref
+	}
+	rest := strings.TrimPrefix(ref, ComponentsRef)
+	comp, pointer, _ := strings.Cut(rest, "/")
+	if comp == EscapePointer(root) {
+		if pointer == "" {
+			return "#"
+		}
+		return "#/" + pointer
+	}
+	return "#/$defs/" + rest
+}
+
+// refComponent returns the name of component ref refers to.
+func refComponent(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, ComponentsRef) {
+		return "", false
+	}
+	comp, _, _ := strings.Cut(strings.TrimPrefix(ref, ComponentsRef), "/")
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(comp), true
+}
+
+// isObjectSchema reports whether the OpenAPI schema v is of object type.
+func isObjectSchema(v any) bool {
+	sch, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	switch typ := sch["type"].(type) {
+	case string:
+		return typ == string(TypeNameObject)
+	case []any:
+		return containsValue(typ, string(TypeNameObject))
+	}
+	return false
+}
+
+func setDefault(sch map[string]any, key string, value any) {
+	if _, ok := sch[key]; !ok {
+		sch[key] = value
+	}
+}
+
+func containsValue(list []any, v any) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestConvertOpenAPI(t *testing.T) {
+	tests := []struct {
+		name string
+		sch  string
+		want string
+	}{
+		{
+			name: "nullable",
+			sch:  `{"properties": {"a": {"type": "string", "nullable": true}, "b": {"type": ["integer"], "nullable": true}, "c": {"type": "string", "nullable": false}}}`,
+			want: `{"properties": {"a": {"type": ["string", "null"]}, "b": {"type": ["integer", "null"]}, "c": {"type": "string"}}}`,
+		},
+		{
+			name: "discriminator mapping",
+			sch: `{"anyOf": [{"$ref": "#/components/schemas/Dog"}, {"$ref": "#/components/schemas/Cat"}],
+				"discriminator": {"propertyName": "kind", "mapping": {"dog": "#/components/schemas/Dog", "cat": "Cat"}}}`,
+			want: `{"type": "object", "x-inheritance": "single-table", "x-discriminator": "kind", "oneOf": [
+				{"$ref": "#/components/schemas/Dog", "x-discriminator-value": "dog"},
+				{"$ref": "#/components/schemas/Cat", "x-discriminator-value": "cat"}]}`,
+		},
+		{
+			name: "discriminator hints kept",
+			sch:  `{"oneOf": [], "x-inheritance": "class-table", "discriminator": {"propertyName": "kind"}}`,
+			want: `{"type": "object", "oneOf": [], "x-inheritance": "class-table", "x-discriminator": "kind"}`,
+		},
+		{
+			name: "example, xml and externalDocs",
+			sch:  `{"example": 1, "xml": {"name": "a"}, "externalDocs": {"url": "x"}, "items": {"example": "b", "examples": ["c"]}, "x-unique": true}`,
+			want: `{"examples": [1], "items": {"examples": ["c"]}, "x-unique": true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sch, want any
+			if err := json.Unmarshal([]byte(tt.sch), &sch); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if convertOpenAPI(sch); !reflect.DeepEqual(sch, want) {
+				data, _ := json.Marshal(sch)
+				t.Errorf("convertOpenAPI() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestComponentRef(t *testing.T) {
+	tests := []struct {
+		ref  string
+		root string
+		want string
+	}{
+		{"#/components/schemas/Pet", "Owner", "#/$defs/Pet"},
+		{"#/components/schemas/Pet/properties/name", "Owner", "#/$defs/Pet/properties/name"},
+		{"#/components/schemas/Pet", "Pet", "#"},
+		{"#/components/schemas/Pet/properties/name", "Pet", "#/properties/name"},
+		{"#/components/schemas/a~1b", "a/b", "#"},
+		{"other.yaml#/components/schemas/Pet", "Pet", "other.yaml#/components/schemas/Pet"},
+		{"#/$defs/Pet", "Pet", "#/$defs/Pet"},
+	}
+	for _, tt := range tests {
+		if got := componentRef(tt.ref, tt.root); got != tt.want {
+			t.Errorf("componentRef(%q, %q) = %q, want %q", tt.ref, tt.root, got, tt.want)
+		}
+	}
+}
+
+const petsOpenAPI = `openapi: 3.0.3
+info: {title: pets, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        tag: {type: string, nullable: true}
+        owner: {$ref: '#/components/schemas/Owner'}
+        status: {$ref: '#/components/schemas/Status'}
+        parent: {$ref: '#/components/schemas/Pet'}
+    Owner:
+      type: object
+      properties:
+        pets: {type: array, items: {$ref: '#/components/schemas/Pet'}}
+        address: {$ref: '#/components/schemas/Address'}
+    Address:
+      type: object
+      properties:
+        city: {type: string}
+    Status:
+      type: string
+      enum: [available, sold]
+    Unused:
+      type: object
+`
+
+func TestOpenAPISchema(t *testing.T) {
+	doc, err := ParseOpenAPI([]byte(petsOpenAPI))
+	if err != nil {
+		t.Fatalf("ParseOpenAPI() = %v", err)
+	}
+	if got, want := doc.Components(true), []string{"Address", "Owner", "Pet", "Unused"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Components(true) = %v, want %v", got, want)
+	}
+	if got, want := doc.Components(false), []string{"Address", "Owner", "Pet", "Status", "Unused"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Components(false) = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name     string
+		declared []string
+		defs     map[string]bool // definitions, by whether they are declared
+	}{
+		{
+			name: "all inlined",
+			defs: map[string]bool{"Owner": false, "Address": false, "Status": false},
+		},
+		{
+			name:     "declared objects",
+			declared: []string{"Pet", "Owner", "Status", "Unused"},
+			defs:     map[string]bool{"Owner": true, "Address": false, "Status": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := doc.Schema("Pet", tt.declared...)
+			if err != nil {
+				t.Fatalf("Schema() = %v", err)
+			}
+			var root map[string]any
+			if err := json.Unmarshal(data, &root); err != nil {
+				t.Fatal(err)
+			}
+			props := root["properties"].(map[string]any)
+			if got := props["parent"].(map[string]any)["$ref"]; got != "#" {
+				t.Errorf("$ref to root = %v, want #", got)
+			}
+			if got := props["tag"].(map[string]any)["type"]; !reflect.DeepEqual(got, []any{"string", "null"}) {
+				t.Errorf("type of nullable = %v", got)
+			}
+
+			defs := root["$defs"].(map[string]any)
+			if len(defs) != len(tt.defs) {
+				t.Errorf("$defs = %v, want %v", defs, tt.defs)
+			}
+			for name, declared := range tt.defs {
+				def, ok := defs[name].(map[string]any)
+				if !ok {
+					t.Errorf("no definition %s", name)
+					continue
+				}
+				if _, got := def[declaredHint]; got != declared {
+					t.Errorf("definition %s declared = %v, want %v", name, got, declared)
+				}
+			}
+			if _, err := FromJSON(bytes.NewReader(data)); err != nil {
+				t.Errorf("FromJSON() = %v", err)
+			}
+		})
+	}
+
+	// schemas are copies, the document is not changed by them
+	if _, err := doc.Schema("Owner", "Pet"); err != nil {
+		t.Fatalf("Schema() = %v", err)
+	}
+	data, err := doc.ComponentSchema("Pet")
+	if err != nil {
+		t.Fatalf("ComponentSchema() = %v", err)
+	}
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := root["$defs"]; ok {
+		t.Errorf("ComponentSchema() has $defs: %s", data)
+	}
+	if _, ok := root[declaredHint]; ok {
+		t.Errorf("ComponentSchema() is declared: %s", data)
+	}
+	if got := root["properties"].(map[string]any)["owner"].(map[string]any)["$ref"]; got != "#/$defs/Owner" {
+		t.Errorf("$ref to Owner = %v, want #/$defs/Owner", got)
+	}
+	if _, err := doc.Schema("Missing"); err == nil {
+		t.Errorf("Schema() of missing component = nil, want error")
+	}
+}
@@ -11,7 +11,7 @@ import (
 func FromJSONFile(filePath string) (*Schema, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
-		return nil, errorst.NewError("failed to open file %s: %w", filePath, err)
+		return nil, errorst.Wrap(err, "failed to open file %s", filePath)
 	}
 
 	defer func() {
@@ -21,11 +21,20 @@ func FromJSONFile(filePath string) (*Schema, error) {
 	return FromJSON(f)
 }
 
-// FromJSON reads from a JSON reader and returns a Schema.
+// FromJSON reads from a JSON reader and returns a Schema,
+// the document is validated against its meta-schema first.
 func FromJSON(r io.Reader) (*Schema, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errorst.Wrap(err, "failed to read JSON")
+	}
+	if err := Validate(data); err != nil {
+		return nil, err
+	}
+
 	var schema Schema
-	if err := json.NewDecoder(r).Decode(&schema); err != nil {
-		return nil, errorst.NewError("failed to unmarshal JSON: %w", err)
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, errorst.Wrap(err, "failed to unmarshal JSON")
 	}
 
 	return &schema, nil
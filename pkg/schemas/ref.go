@@ -2,6 +2,10 @@ package schemas
 
 import (
 	"github.com/thorn-jmh/errorst"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"sort"
+	"strings"
 )
 
 var (
@@ -17,3 +21,62 @@ const (
 	RefTypeHTTPS   RefType = "https"
 	RefTypeUnknown RefType = "unknown"
 )
+
+// GetRefType returns the type of resource referenced by ref, references
+// inside the document and relative references are of file type.
+func GetRefType(ref string) (RefType, error) {
+	uri, err := url.Parse(ref)
+	if err != nil {
+		return RefTypeUnknown, errorst.Wrap(ErrGetRefType, "invalid $ref %s: %v", ref, err)
+	}
+	switch RefType(uri.Scheme) {
+	case "", RefTypeFile:
+		return RefTypeFile, nil
+	case RefTypeHTTP, RefTypeHTTPS:
+		return RefType(uri.Scheme), nil
+	default:
+		return RefTypeUnknown, errorst.Wrap(ErrUnsupportedRefSchema, "unsupported $ref %s", ref)
+	}
+}
+
+// ExternalRefs returns the sorted values of $ref in JSON or YAML document
+// data which refer to other documents. References by the $id of the
+// document are not external.
+func ExternalRefs(data []byte) ([]string, error) {
+	// yaml for OpenAPI documents, which is a superset of JSON
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errorst.Wrap(err, "failed to unmarshal document")
+	}
+	var id string
+	if root, ok := doc.(map[string]any); ok {
+		id, _ = root["$id"].(string)
+	}
+
+	var external []string
+	for _, ref := range collectRefs(doc, nil) {
+		if target, _, _ := strings.Cut(ref, "#"); target != "" && target != id {
+			external = append(external, ref)
+		}
+	}
+	sort.Strings(external)
+	return external, nil
+}
+
+// collectRefs collects values of $ref in JSON document v.
+func collectRefs(v any, refs []string) []string {
+	switch v := v.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok {
+			refs = append(refs, ref)
+		}
+		for _, child := range v {
+			refs = collectRefs(child, refs)
+		}
+	case []any:
+		for _, child := range v {
+			refs = collectRefs(child, refs)
+		}
+	}
+	return refs
+}
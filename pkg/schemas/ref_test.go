@@ -0,0 +1,47 @@
+package schemas
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExternalRefs(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "local",
+			doc:  `{"$id": "Order", "properties": {"a": {"$ref": "#/$defs/a"}, "b": {"$ref": "#"}, "c": {"$ref": "Order#/$defs/a"}, "d": {"$ref": "#node"}}}`,
+		},
+		{
+			name: "files and urls",
+			doc:  `{"$id": "Order", "properties": {"b": {"$ref": "common.json#/$defs/b"}, "a": {"items": [{"$ref": "https://example.com/a.json"}]}}}`,
+			want: []string{"common.json#/$defs/b", "https://example.com/a.json"},
+		},
+		{
+			name: "id url",
+			doc:  `{"$id": "https://example.com/order.json", "properties": {"a": {"$ref": "https://example.com/order.json#/$defs/a"}}}`,
+		},
+		{
+			name: "openapi",
+			doc:  "components:\n  schemas:\n    Pet: {properties: {owner: {$ref: '#/components/schemas/Owner'}, tag: {$ref: 'tags.yaml#/Tag'}}}\n",
+			want: []string{"tags.yaml#/Tag"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExternalRefs([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("ExternalRefs() = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExternalRefs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if _, err := ExternalRefs([]byte("{")); err == nil {
+		t.Errorf("ExternalRefs() of invalid document = nil, want error")
+	}
+}
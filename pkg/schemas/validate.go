@@ -0,0 +1,253 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/thorn-jmh/errorst"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// >>>>>>>>>>>> validate schema documents against the meta-schema of their drafts >>>>>>>>>>>>>>>
+
+var (
+	ErrInvalidSchema      = errorst.NewError("invalid schema")
+	ErrUnsupportedVersion = errorst.NewError("unsupported $schema")
+)
+
+// Draft is a version of JSON Schema.
+type Draft string
+
+const (
+	Draft4    Draft = "draft-04"
+	Draft6    Draft = "draft-06"
+	Draft7    Draft = "draft-07"
+	Draft2019 Draft = "2019-09"
+	Draft2020 Draft = "2020-12"
+)
+
+// DefaultDraft is the draft of schemas without $schema.
+const DefaultDraft = Draft2020
+
+// metaSchemas are URLs of meta-schemas bundled for each draft.
+var metaSchemas = map[Draft]string{
+	Draft4:    "http://json-schema.org/draft-04/schema",
+	Draft6:    "http://json-schema.org/draft-06/schema",
+	Draft7:    "http://json-schema.org/draft-07/schema",
+	Draft2019: "https://json-schema.org/draft/2019-09/schema",
+	Draft2020: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// DraftOf returns the draft of $schema uri, DefaultDraft if uri is empty.
+func DraftOf(uri string) (Draft, error) {
+	if uri == "" {
+		return DefaultDraft, nil
+	}
+	normalized := strings.TrimSuffix(strings.TrimSuffix(uri, "#"), "/")
+	normalized = strings.Replace(normalized, "https://", "http://", 1)
+	for draft, meta := range metaSchemas {
+		if normalized == strings.Replace(meta, "https://", "http://", 1) {
+			return draft, nil
+		}
+	}
+	return "", errorst.Wrap(ErrUnsupportedVersion, "unsupported $schema %s, supported drafts are 2020-12, 2019-09, draft-07, draft-06 and draft-04", uri)
+}
+
+// Draft returns the draft of s by its $schema.
+func (s *Schema) Draft() (Draft, error) {
+	return DraftOf(s.Version)
+}
+
+// metaSchemaCache holds meta-schemas compiled once for each draft.
+var metaSchemaCache struct {
+	mu      sync.Mutex
+	schemas map[Draft]*jsonschema.Schema
+}
+
+// metaSchema returns the compiled meta-schema of draft.
+func metaSchema(draft Draft) (*jsonschema.Schema, error) {
+	metaSchemaCache.mu.Lock()
+	defer metaSchemaCache.mu.Unlock()
+	if meta, ok := metaSchemaCache.schemas[draft]; ok {
+		return meta, nil
+	}
+	meta, err := jsonschema.Compile(metaSchemas[draft])
+	if err != nil {
+		return nil, err
+	}
+	if metaSchemaCache.schemas == nil {
+		metaSchemaCache.schemas = make(map[Draft]*jsonschema.Schema)
+	}
+	metaSchemaCache.schemas[draft] = meta
+	return meta, nil
+}
+
+// Validate validates the schema document data against the meta-schema of its
+// $schema. Each problem is reported with the JSON Pointer of the offending
+// keyword. Unknown keywords which are not prefixed by ExtensionPrefix are
+// likely typos, they are warned about but allowed.
+func Validate(data []byte) error {
+	// first: decode and find the draft
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return errorst.Wrap(err, "failed to unmarshal JSON")
+	}
+	var version string
+	if root, ok := doc.(map[string]any); ok {
+		if v, ok := root["$schema"]; ok {
+			if version, ok = v.(string); !ok {
+				return errorst.Wrap(ErrInvalidSchema, "#/$schema: must be a string")
+			}
+		}
+	}
+	draft, err := DraftOf(version)
+	if err != nil {
+		return err
+	}
+
+	// second: validate against meta-schema, and check keywords
+	meta, err := metaSchema(draft)
+	if err != nil {
+		return errorst.Wrap(err, "failed to load meta-schema of %s", draft)
+	}
+	var problems []string
+	if err := meta.Validate(doc); err != nil {
+		var verr *jsonschema.ValidationError
+		if !errors.As(err, &verr) {
+			return errorst.Wrap(err, "failed to validate against meta-schema of %s", draft)
+		}
+		problems = append(problems, ValidationProblems(verr)...)
+	}
+	for _, pointer := range UnknownKeywords(doc) {
+		logrus.Warnf("%s: unknown keyword, prefix extensions with %s", pointer, ExtensionPrefix)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return errorst.Wrap(ErrInvalidSchema, "%d problems against %s meta-schema:\n  %s", len(problems), draft, strings.Join(problems, "\n  "))
+}
+
+// ValidationProblems returns leaf causes of err, one per location.
+func ValidationProblems(err *jsonschema.ValidationError) []string {
+	var (
+		problems []string
+		seen     = make(map[string]bool)
+		walk     func(err *jsonschema.ValidationError)
+	)
+	walk = func(err *jsonschema.ValidationError) {
+		if len(err.Causes) > 0 {
+			for _, cause := range err.Causes {
+				walk(cause)
+			}
+			return
+		}
+		if !seen[err.InstanceLocation] {
+			seen[err.InstanceLocation] = true
+			problems = append(problems, fmt.Sprintf("#%s: %s", err.InstanceLocation, err.Message))
+		}
+	}
+	walk(err)
+	return problems
+}
+
+// knownKeywords are keywords of all supported drafts.
+var knownKeywords = map[string]bool{}
+
+func init() {
+	for _, keyword := range []string{
+		// core
+		"$schema", "$id", "id", "$ref", "$anchor", "$dynamicRef", "$dynamicAnchor",
+		"$recursiveRef", "$recursiveAnchor", "$vocabulary", "$comment", "$defs", "definitions",
+		// applicators
+		"allOf", "anyOf", "oneOf", "not", "if", "then", "else", "dependentSchemas", "dependencies",
+		"prefixItems", "items", "additionalItems", "contains", "properties", "patternProperties",
+		"additionalProperties", "propertyNames", "unevaluatedItems", "unevaluatedProperties",
+		// validation
+		"type", "enum", "const", "multipleOf", "maximum", "exclusiveMaximum", "minimum",
+		"exclusiveMinimum", "maxLength", "minLength", "pattern", "maxItems", "minItems",
+		"uniqueItems", "maxContains", "minContains", "maxProperties", "minProperties",
+		"required", "dependentRequired", "format",
+		// content and meta-data
+		"contentEncoding", "contentMediaType", "contentSchema", "title", "description",
+		"default", "deprecated", "readOnly", "writeOnly", "examples",
+	} {
+		knownKeywords[keyword] = true
+	}
+}
+
+// schemaKeywords are keywords whose values are schemas, by their shapes.
+var (
+	schemaKeywords = []string{
+		"additionalItems", "additionalProperties", "contains", "contentSchema", "else", "if",
+		"items", "not", "propertyNames", "then", "unevaluatedItems", "unevaluatedProperties",
+	}
+	schemaListKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems", "items"}
+	schemaMapKeywords  = []string{"$defs", "definitions", "dependentSchemas", "dependencies", "patternProperties", "properties"}
+)
+
+// UnknownKeywords returns JSON Pointers of unknown keywords in the decoded
+// schema document doc, which are likely typos. Keywords prefixed by
+// ExtensionPrefix are known.
+func UnknownKeywords(doc any) []string {
+	return unknownKeywords("#", doc)
+}
+
+// unknownKeywords returns pointers of unknown keywords in schema v at pointer
+// and its subschemas.
+func unknownKeywords(pointer string, v any) []string {
+	sch, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var pointers []string
+	keys := make([]string, 0, len(sch))
+	for k := range sch {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !knownKeywords[k] && !strings.HasPrefix(k, ExtensionPrefix) {
+			pointers = append(pointers, pointer+"/"+EscapePointer(k))
+		}
+	}
+
+	for _, k := range schemaKeywords {
+		if sub, ok := sch[k]; ok {
+			pointers = append(pointers, unknownKeywords(pointer+"/"+k, sub)...)
+		}
+	}
+	for _, k := range schemaListKeywords {
+		if subs, ok := sch[k].([]any); ok {
+			for i, sub := range subs {
+				pointers = append(pointers, unknownKeywords(fmt.Sprintf("%s/%s/%d", pointer, k, i), sub)...)
+			}
+		}
+	}
+	for _, k := range schemaMapKeywords {
+		if subs, ok := sch[k].(map[string]any); ok {
+			names := make([]string, 0, len(subs))
+			for name := range subs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				pointers = append(pointers, unknownKeywords(pointer+"/"+k+"/"+EscapePointer(name), subs[name])...)
+			}
+		}
+	}
+	return pointers
+}
+
+// EscapePointer escapes name as a JSON Pointer reference token.
+func EscapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
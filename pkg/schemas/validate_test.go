@@ -0,0 +1,125 @@
+package schemas
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		problems []string // substrings of error, nil if valid
+		warnings []string // pointers of warned unknown keywords
+	}{
+		{
+			name:   "default draft",
+			schema: `{"type": "object", "properties": {"name": {"type": "string", "maxLength": 3}}}`,
+		},
+		{
+			name:   "draft-04",
+			schema: `{"$schema": "http://json-schema.org/draft-04/schema#", "type": "object", "definitions": {"id": {"type": "integer", "minimum": 0, "exclusiveMinimum": true}}}`,
+		},
+		{
+			name:   "draft-07",
+			schema: `{"$schema": "http://json-schema.org/draft-07/schema#", "type": "array", "items": [{"type": "string"}]}`,
+		},
+		{
+			name:   "2019-09",
+			schema: `{"$schema": "https://json-schema.org/draft/2019-09/schema", "type": "object", "$defs": {"a": {"type": "string"}}}`,
+		},
+		{
+			name:     "wrong type",
+			schema:   `{"type": "object", "properties": {"name": {"type": "strin"}}}`,
+			problems: []string{"#/properties/name/type"},
+		},
+		{
+			name:     "draft-04 exclusiveMinimum in 2020-12",
+			schema:   `{"type": "integer", "minimum": 0, "exclusiveMinimum": true}`,
+			problems: []string{"#/exclusiveMinimum"},
+		},
+		{
+			name:     "several problems",
+			schema:   `{"type": "object", "required": "name", "properties": {"a": {"minLength": -1}}}`,
+			problems: []string{"2 problems", "#/required", "#/properties/a/minLength"},
+		},
+		{
+			name:     "unknown keywords",
+			schema:   `{"type": "object", "properties": {"a/b": {"type": "string", "maxLen": 3}}, "$defs": {"x": {"nullable": true}}, "x-table": "t"}`,
+			warnings: []string{"#/$defs/x/nullable", "#/properties/a~1b/maxLen"},
+		},
+		{
+			name:     "unsupported draft",
+			schema:   `{"$schema": "http://json-schema.org/draft-03/schema#"}`,
+			problems: []string{"unsupported $schema"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := test.NewGlobal()
+			defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+			err := Validate([]byte(tt.schema))
+			if tt.problems == nil && err != nil {
+				t.Fatalf("Validate() = %v, want nil", err)
+			}
+			if tt.problems != nil && !errors.Is(err, ErrInvalidSchema) && !errors.Is(err, ErrUnsupportedVersion) {
+				t.Fatalf("Validate() = %v, want invalid schema", err)
+			}
+			for _, problem := range tt.problems {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("Validate() = %v, want problem %s", err, problem)
+				}
+			}
+
+			var warned []string
+			for _, entry := range hook.AllEntries() {
+				if entry.Level == logrus.WarnLevel {
+					warned = append(warned, entry.Message)
+				}
+			}
+			if len(warned) != len(tt.warnings) {
+				t.Fatalf("warned %q, want %q", warned, tt.warnings)
+			}
+			for i, pointer := range tt.warnings {
+				if !strings.HasPrefix(warned[i], pointer+": unknown keyword") {
+					t.Errorf("warning %q, want of %s", warned[i], pointer)
+				}
+			}
+		})
+	}
+}
+
+// TestValidateConcurrent shares compiled meta-schemas, run it with -race.
+func TestValidateConcurrent(t *testing.T) {
+	docs := []string{
+		`{"type": "object"}`,
+		`{"$schema": "http://json-schema.org/draft-04/schema#", "type": "object"}`,
+		`{"$schema": "http://json-schema.org/draft-07/schema#", "type": "strin"}`,
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for j, doc := range docs {
+			wg.Add(1)
+			go func(j int, doc string) {
+				defer wg.Done()
+				if err := Validate([]byte(doc)); (err == nil) != (j < 2) {
+					t.Errorf("Validate(%s) = %v", doc, err)
+				}
+			}(j, doc)
+		}
+	}
+	wg.Wait()
+
+	first, err := metaSchema(Draft2020)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := metaSchema(Draft2020); again != first {
+		t.Errorf("meta-schema of %s compiled again", Draft2020)
+	}
+}